@@ -23,6 +23,7 @@ const (
 	ModProtected
 	ModFinal
 	ModAbstract
+	ModStatic
 )
 
 type Node interface {
@@ -161,8 +162,8 @@ func (ioe InstanceOfExpression) Accept(Visitor) {
 	panic("implement me")
 }
 
-func (InstanceOfExpression) Pos() int {
-	panic("implement me")
+func (ioe InstanceOfExpression) Pos() int {
+	return ioe.Token.Pos
 }
 
 func (InstanceOfExpression) End() int {
@@ -170,7 +171,7 @@ func (InstanceOfExpression) End() int {
 }
 
 func (InstanceOfExpression) TokenLiteral() string {
-	panic("implement me")
+	return "instanceof"
 }
 
 func (ioe InstanceOfExpression) String() string {
@@ -226,7 +227,9 @@ func (ConditionalExpression) expressionNode() {}
 type AssignmentExpression struct {
 	Token token.Token
 	Left  Expression
-	Right Expression
+	// Operator of compound assignment like `+` for `$x += 1`, empty for plain one
+	Operator string
+	Right    Expression
 }
 
 func (ae AssignmentExpression) Accept(Visitor) {
@@ -246,7 +249,9 @@ func (AssignmentExpression) TokenLiteral() string {
 }
 
 // String ...
-func (ae AssignmentExpression) String() string { return ae.Left.String() + " = " + ae.Right.String() }
+func (ae AssignmentExpression) String() string {
+	return ae.Left.String() + " " + ae.Operator + "= " + ae.Right.String()
+}
 
 // expressionNode ...
 func (AssignmentExpression) expressionNode() {}
@@ -464,6 +469,8 @@ type UnaryExpression struct {
 	Right    Expression
 }
 
+func (UnaryExpression) Accept(Visitor) { panic("implement me") }
+
 func (ue UnaryExpression) Pos() int { return ue.Token.Pos }
 
 func (UnaryExpression) End() int {
@@ -475,7 +482,12 @@ func (UnaryExpression) TokenLiteral() string {
 }
 
 // String ...
-func (ue UnaryExpression) String() string { return ue.Op + ue.Right.String() }
+func (ue UnaryExpression) String() string {
+	if ue.IsPrefix {
+		return ue.Op + ue.Right.String()
+	}
+	return ue.Right.String() + ue.Op
+}
 
 // expressionNode ...
 func (UnaryExpression) expressionNode() {}
@@ -529,12 +541,45 @@ func (WhileExpression) TokenLiteral() string {
 }
 
 func (we WhileExpression) String() string {
-	return "while (" + we.Body.String() + ")" + we.Body.String()
+	return "while (" + we.Condition.String() + ") " + we.Body.String()
+}
+
+func (WhileExpression) Accept(Visitor) {
+	panic("implement me")
 }
 
 // expressionNode ...
 func (WhileExpression) expressionNode() {}
 
+// DoWhileExpression represents
+// do { ... } while ($condition)
+type DoWhileExpression struct {
+	Token     token.Token
+	Body      *BlockStatement
+	Condition Expression
+}
+
+func (dwe DoWhileExpression) Pos() int { return dwe.Token.Pos }
+
+func (DoWhileExpression) End() int {
+	panic("implement me")
+}
+
+func (DoWhileExpression) TokenLiteral() string {
+	return "do"
+}
+
+func (dwe DoWhileExpression) String() string {
+	return "do " + dwe.Body.String() + " while (" + dwe.Condition.String() + ")"
+}
+
+func (DoWhileExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (DoWhileExpression) expressionNode() {}
+
 // ForEachExpression represents
 // foreach($array as $key => $value) {}
 type ForEachExpression struct {
@@ -577,12 +622,14 @@ func (fee ForEachExpression) String() string {
 // expressionNode ...
 func (ForEachExpression) expressionNode() {}
 
-// ForExpression ...
+// ForExpression represents
+// for ($i = 0, $j = 10; $i < $j; $i = $i + 1, $j = $j - 1) {}
 type ForExpression struct {
 	Token     token.Token
-	Init      Expression
+	Init      []Expression
 	Condition Expression
-	Inc       Expression
+	Inc       []Expression
+	Body      *BlockStatement
 }
 
 func (fe ForExpression) Pos() int {
@@ -594,11 +641,29 @@ func (ForExpression) End() int {
 }
 
 func (ForExpression) TokenLiteral() string {
-	panic("implement me")
+	return "for"
 }
 
-func (ForExpression) String() string {
-	panic("implement me")
+// String ...
+func (fe ForExpression) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("for (" + expressions(fe.Init) + "; ")
+	if fe.Condition != nil {
+		out.WriteString(fe.Condition.String())
+	}
+	out.WriteString("; " + expressions(fe.Inc) + ") ")
+	out.WriteString(fe.Body.String())
+
+	return out.String()
+}
+
+// expressions returns comma separated expressions
+func expressions(list []Expression) string {
+	strs := make([]string, len(list))
+	for i, e := range list {
+		strs[i] = e.String()
+	}
+	return strings.Join(strs, ", ")
 }
 
 func (ForExpression) Accept(Visitor) {
@@ -725,13 +790,93 @@ func (a Arg) String() string {
 	return out.String()
 }
 
+// SpreadExpression represents unpacking of an array into call arguments
+// f(...$values)
+type SpreadExpression struct {
+	Token token.Token
+	Value Expression
+}
+
+func (se SpreadExpression) Pos() int {
+	return se.Token.Pos
+}
+
+func (SpreadExpression) End() int {
+	panic("implement me")
+}
+
+func (SpreadExpression) TokenLiteral() string {
+	return "..."
+}
+
+// String ...
+func (se SpreadExpression) String() string {
+	return "..." + se.Value.String()
+}
+
+func (SpreadExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (SpreadExpression) expressionNode() {}
+
+// NamedArgument represents an argument passed by the name of parameter
+// makeUser(name: "x", admin: true)
+type NamedArgument struct {
+	Token token.Token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na NamedArgument) Pos() int {
+	return na.Token.Pos
+}
+
+func (NamedArgument) End() int {
+	panic("implement me")
+}
+
+func (na NamedArgument) TokenLiteral() string {
+	return na.Name.Value
+}
+
+// String ...
+func (na NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
+func (NamedArgument) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (NamedArgument) expressionNode() {}
+
+// BindVar is a variable a closure captures
+// `use ($value, &$reference)`
+type BindVar struct {
+	Token       token.Token
+	Variable    *VariableExpression
+	IsReference bool
+}
+
+// String ...
+func (bv BindVar) String() string {
+	if bv.IsReference {
+		return "&" + bv.Variable.String()
+	}
+	return bv.Variable.String()
+}
+
 // FunctionDeclarationExpression is an expression like
-// `function <Name> (<Args> <Variadic>): <ReturnType> { <Block> }`
+// `function <Name> (<Args> <Variadic>) use (<BindVars>): <ReturnType> { <Block> }`
 type FunctionDeclarationExpression struct {
 	Token      token.Token
 	Anonymous  bool
 	Name       *Identifier
 	Args       []*Arg
+	BindVars   []*BindVar
 	ReturnType *Identifier
 	Block      *BlockStatement
 }
@@ -752,8 +897,9 @@ func (fde FunctionDeclarationExpression) String() string {
 	out := bytes.Buffer{}
 	out.WriteString("function")
 	if !fde.Anonymous {
-		out.WriteString(" " + fde.Name.String() + "(")
+		out.WriteString(" " + fde.Name.String())
 	}
+	out.WriteString("(")
 
 	args := make([]string, len(fde.Args))
 	for i, a := range fde.Args {
@@ -762,11 +908,22 @@ func (fde FunctionDeclarationExpression) String() string {
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
 
+	if len(fde.BindVars) != 0 {
+		vars := make([]string, len(fde.BindVars))
+		for i, bv := range fde.BindVars {
+			vars[i] = bv.String()
+		}
+		out.WriteString(" use (" + strings.Join(vars, ", ") + ")")
+	}
+
 	if fde.ReturnType != nil {
 		out.WriteString(": " + fde.ReturnType.String())
 	}
 
-	out.WriteString(" " + fde.Block.String())
+	// abstract methods have no body
+	if fde.Block != nil {
+		out.WriteString(" " + fde.Block.String())
+	}
 
 	return out.String()
 }
@@ -784,6 +941,41 @@ type MethodDeclarationExpression struct {
 	FunctionDeclarationExpression
 }
 
+// PropertyDeclarationExpression represents
+// public $name = "default"
+type PropertyDeclarationExpression struct {
+	Token        token.Token
+	Access       int32
+	IsStatic     bool
+	Name         *VariableExpression
+	DefaultValue Expression
+}
+
+func (pde PropertyDeclarationExpression) Pos() int { return pde.Token.Pos }
+
+func (PropertyDeclarationExpression) End() int {
+	panic("implement me")
+}
+
+func (PropertyDeclarationExpression) TokenLiteral() string {
+	panic("implement me")
+}
+
+// String ...
+func (pde PropertyDeclarationExpression) String() string {
+	if pde.DefaultValue == nil {
+		return pde.Name.String()
+	}
+	return pde.Name.String() + " = " + pde.DefaultValue.String()
+}
+
+func (PropertyDeclarationExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (PropertyDeclarationExpression) expressionNode() {}
+
 // Module is the whole program for file
 type Module struct {
 	Token      token.Token
@@ -987,6 +1179,8 @@ type ClassDeclarationExpression struct {
 	IsAbstract bool
 	IsFinal    bool
 	Name       *Identifier
+	Parent     *Identifier
+	Interfaces []*Identifier
 	Block      *BlockStatement
 }
 
@@ -1003,7 +1197,173 @@ func (ClassDeclarationExpression) TokenLiteral() string {
 }
 
 func (cde ClassDeclarationExpression) String() string {
-	return "class " + cde.Name.String() + " " + cde.Block.String()
+	out := bytes.Buffer{}
+	out.WriteString("class " + cde.Name.String() + " ")
+	if cde.Parent != nil {
+		out.WriteString("extends " + cde.Parent.String() + " ")
+	}
+	if len(cde.Interfaces) != 0 {
+		out.WriteString("implements " + identifiers(cde.Interfaces) + " ")
+	}
+	out.WriteString(cde.Block.String())
+
+	return out.String()
+}
+
+// InterfaceDeclarationExpression represents
+// interface Name extends Parent { public function method(); }
+type InterfaceDeclarationExpression struct {
+	Token   token.Token
+	Name    *Identifier
+	Parents []*Identifier
+	Block   *BlockStatement
+}
+
+func (ide InterfaceDeclarationExpression) Pos() int {
+	return ide.Token.Pos
+}
+
+func (InterfaceDeclarationExpression) End() int {
+	panic("implement me")
+}
+
+func (InterfaceDeclarationExpression) TokenLiteral() string {
+	return "interface"
+}
+
+// String ...
+func (ide InterfaceDeclarationExpression) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("interface " + ide.Name.String() + " ")
+	if len(ide.Parents) != 0 {
+		out.WriteString("extends " + identifiers(ide.Parents) + " ")
+	}
+	out.WriteString(ide.Block.String())
+
+	return out.String()
+}
+
+func (InterfaceDeclarationExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (InterfaceDeclarationExpression) expressionNode() {}
+
+// TraitDeclarationExpression represents
+// trait Name { public function method() { ... } }
+type TraitDeclarationExpression struct {
+	Token token.Token
+	Name  *Identifier
+	Block *BlockStatement
+}
+
+func (tde TraitDeclarationExpression) Pos() int {
+	return tde.Token.Pos
+}
+
+func (TraitDeclarationExpression) End() int {
+	panic("implement me")
+}
+
+func (TraitDeclarationExpression) TokenLiteral() string {
+	return "trait"
+}
+
+// String ...
+func (tde TraitDeclarationExpression) String() string {
+	return "trait " + tde.Name.String() + " " + tde.Block.String()
+}
+
+func (TraitDeclarationExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (TraitDeclarationExpression) expressionNode() {}
+
+// TraitUseExpression represents `use` in a class body like
+// use First, Second { First::method insteadof Second; Second::method as other; }
+type TraitUseExpression struct {
+	Token  token.Token
+	Traits []*Identifier
+	Rules  []*TraitRule
+}
+
+func (tue TraitUseExpression) Pos() int {
+	return tue.Token.Pos
+}
+
+func (TraitUseExpression) End() int {
+	panic("implement me")
+}
+
+func (TraitUseExpression) TokenLiteral() string {
+	return "use"
+}
+
+// String ...
+func (tue TraitUseExpression) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("use " + identifiers(tue.Traits))
+	if len(tue.Rules) != 0 {
+		out.WriteString(" {\n")
+		for _, rule := range tue.Rules {
+			out.WriteString(fourSpaces + rule.String() + ";\n")
+		}
+		out.WriteString("}")
+	}
+
+	return out.String()
+}
+
+func (TraitUseExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (TraitUseExpression) expressionNode() {}
+
+// TraitRule resolves a conflict between traits with either
+// First::method insteadof Second or Second::method as protected alias
+type TraitRule struct {
+	Token     token.Token
+	Trait     *Identifier
+	Method    *Identifier
+	InsteadOf []*Identifier
+	Alias     *Identifier
+	Access    int32
+	HasAccess bool
+}
+
+// String ...
+func (tr TraitRule) String() string {
+	out := bytes.Buffer{}
+	if tr.Trait != nil {
+		out.WriteString(tr.Trait.String() + "::")
+	}
+	out.WriteString(tr.Method.String())
+	if len(tr.InsteadOf) != 0 {
+		out.WriteString(" insteadof " + identifiers(tr.InsteadOf))
+		return out.String()
+	}
+	out.WriteString(" as")
+	if tr.HasAccess {
+		out.WriteString(" " + [...]string{"public", "private", "protected"}[tr.Access])
+	}
+	if tr.Alias != nil {
+		out.WriteString(" " + tr.Alias.String())
+	}
+	return out.String()
+}
+
+// identifiers joins names with a comma
+func identifiers(list []*Identifier) string {
+	names := make([]string, len(list))
+	for i, name := range list {
+		names[i] = name.String()
+	}
+	return strings.Join(names, ", ")
 }
 
 func (ClassDeclarationExpression) Accept(Visitor) {
@@ -1044,6 +1404,8 @@ type FetchExpression struct {
 	Token token.Token
 	Left  Expression
 	Right Expression
+	// IsNullsafe is set for `$object?->property`
+	IsNullsafe bool
 }
 
 func (fe FetchExpression) Pos() int {
@@ -1060,6 +1422,9 @@ func (FetchExpression) TokenLiteral() string {
 
 // String ...
 func (fe FetchExpression) String() string {
+	if fe.IsNullsafe {
+		return fe.Left.String() + "?->" + fe.Right.String()
+	}
 	return fe.Left.String() + "->" + fe.Right.String()
 }
 
@@ -1069,3 +1434,330 @@ func (FetchExpression) Accept(Visitor) {
 
 // expressionNode ...
 func (FetchExpression) expressionNode() {}
+
+// StaticFetchExpression represents
+// parent::method($args)
+type StaticFetchExpression struct {
+	Token token.Token
+	Left  Expression
+	Right Expression
+}
+
+func (sfe StaticFetchExpression) Pos() int {
+	return sfe.Token.Pos
+}
+
+func (StaticFetchExpression) End() int {
+	panic("implement me")
+}
+
+func (StaticFetchExpression) TokenLiteral() string {
+	return "::"
+}
+
+// String ...
+func (sfe StaticFetchExpression) String() string {
+	return sfe.Left.String() + "::" + sfe.Right.String()
+}
+
+func (StaticFetchExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (StaticFetchExpression) expressionNode() {}
+
+// TryExpression represents
+// try { ... } catch (Exception $e) { ... } finally { ... }
+type TryExpression struct {
+	Token   token.Token
+	Block   *BlockStatement
+	Catches []*CatchClause
+	Finally *BlockStatement
+}
+
+func (te TryExpression) Pos() int {
+	return te.Token.Pos
+}
+
+func (TryExpression) End() int {
+	panic("implement me")
+}
+
+func (TryExpression) TokenLiteral() string {
+	return "try"
+}
+
+// String ...
+func (te TryExpression) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("try " + te.Block.String())
+	for _, catch := range te.Catches {
+		out.WriteString(" " + catch.String())
+	}
+	if te.Finally != nil {
+		out.WriteString(" finally " + te.Finally.String())
+	}
+
+	return out.String()
+}
+
+func (TryExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (TryExpression) expressionNode() {}
+
+// CatchClause represents `catch (Exception $e) { ... }` of a try expression,
+// the variable is optional
+type CatchClause struct {
+	Token    token.Token
+	Type     *Identifier
+	Variable *VariableExpression
+	Block    *BlockStatement
+}
+
+// String ...
+func (cc CatchClause) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("catch (" + cc.Type.String())
+	if cc.Variable != nil {
+		out.WriteString(" " + cc.Variable.String())
+	}
+	out.WriteString(") " + cc.Block.String())
+
+	return out.String()
+}
+
+// ThrowExpression represents
+// throw new Exception("message")
+type ThrowExpression struct {
+	Token token.Token
+	Value Expression
+}
+
+func (te ThrowExpression) Pos() int {
+	return te.Token.Pos
+}
+
+func (ThrowExpression) End() int {
+	panic("implement me")
+}
+
+func (ThrowExpression) TokenLiteral() string {
+	return "throw"
+}
+
+// String ...
+func (te ThrowExpression) String() string {
+	return "throw " + te.Value.String()
+}
+
+func (ThrowExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (ThrowExpression) expressionNode() {}
+
+// BreakExpression represents
+// break 2
+type BreakExpression struct {
+	Token  token.Token
+	Levels int64
+}
+
+func (be BreakExpression) Pos() int {
+	return be.Token.Pos
+}
+
+func (BreakExpression) End() int {
+	panic("implement me")
+}
+
+func (BreakExpression) TokenLiteral() string {
+	return "break"
+}
+
+// String ...
+func (be BreakExpression) String() string {
+	return loopControl("break", be.Levels)
+}
+
+func (BreakExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (BreakExpression) expressionNode() {}
+
+// ContinueExpression represents
+// continue 2
+type ContinueExpression struct {
+	Token  token.Token
+	Levels int64
+}
+
+func (ce ContinueExpression) Pos() int {
+	return ce.Token.Pos
+}
+
+func (ContinueExpression) End() int {
+	panic("implement me")
+}
+
+func (ContinueExpression) TokenLiteral() string {
+	return "continue"
+}
+
+// String ...
+func (ce ContinueExpression) String() string {
+	return loopControl("continue", ce.Levels)
+}
+
+func (ContinueExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (ContinueExpression) expressionNode() {}
+
+// loopControl omits the number of loops when only the innermost one is left
+func loopControl(keyword string, levels int64) string {
+	if levels == 1 {
+		return keyword
+	}
+	return keyword + " " + strconv.FormatInt(levels, 10)
+}
+
+// MatchExpression represents
+// match ($x) { 1, 2 => "low", Int if $x > 10 => "big", default => "other" }
+type MatchExpression struct {
+	Token   token.Token
+	Subject Expression
+	Arms    []*MatchArm
+}
+
+func (me MatchExpression) Pos() int {
+	return me.Token.Pos
+}
+
+func (MatchExpression) End() int {
+	panic("implement me")
+}
+
+func (MatchExpression) TokenLiteral() string {
+	return "match"
+}
+
+// String ...
+func (me MatchExpression) String() string {
+	arms := make([]string, len(me.Arms))
+	for i, arm := range me.Arms {
+		arms[i] = arm.String()
+	}
+	return "match (" + me.Subject.String() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+func (MatchExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (MatchExpression) expressionNode() {}
+
+// MatchArm represents one arm of match
+// 1, 2 if $guard => $result
+// default arm has no conditions
+type MatchArm struct {
+	Token      token.Token
+	Conditions []Expression
+	Guard      Expression
+	Result     Expression
+}
+
+// IsDefault ...
+func (ma MatchArm) IsDefault() bool {
+	return len(ma.Conditions) == 0
+}
+
+// String ...
+func (ma MatchArm) String() string {
+	s := "default"
+	if !ma.IsDefault() {
+		s = expressions(ma.Conditions)
+	}
+	if ma.Guard != nil {
+		s += " if " + ma.Guard.String()
+	}
+	return s + " => " + ma.Result.String()
+}
+
+// SwitchExpression represents
+// switch ($x) { case 1: case 2: $low = true; break; default: $low = false }
+type SwitchExpression struct {
+	Token   token.Token
+	Subject Expression
+	Cases   []*CaseClause
+}
+
+func (se SwitchExpression) Pos() int {
+	return se.Token.Pos
+}
+
+func (SwitchExpression) End() int {
+	panic("implement me")
+}
+
+func (SwitchExpression) TokenLiteral() string {
+	return "switch"
+}
+
+// String ...
+func (se SwitchExpression) String() string {
+	out := bytes.Buffer{}
+	out.WriteString("switch (" + se.Subject.String() + ") {\n")
+	for _, c := range se.Cases {
+		out.WriteString(c.String())
+	}
+	out.WriteString("}")
+
+	return out.String()
+}
+
+func (SwitchExpression) Accept(Visitor) {
+	panic("implement me")
+}
+
+// expressionNode ...
+func (SwitchExpression) expressionNode() {}
+
+// CaseClause represents one case of switch
+// case 1: $low = true
+// default case has no value
+type CaseClause struct {
+	Token token.Token
+	Value Expression
+	Body  *BlockStatement
+}
+
+// IsDefault ...
+func (cc CaseClause) IsDefault() bool {
+	return cc.Value == nil
+}
+
+// String ...
+func (cc CaseClause) String() string {
+	out := bytes.Buffer{}
+	if cc.IsDefault() {
+		out.WriteString("default:\n")
+	} else {
+		out.WriteString("case " + cc.Value.String() + ":\n")
+	}
+	for _, st := range cc.Body.Statements {
+		out.WriteString(fourSpaces + st.String() + "\n")
+	}
+
+	return out.String()
+}
@@ -305,6 +305,7 @@ func TestArrayLiteral_String(t *testing.T) {
 
 func TestIndexExpression_String(t *testing.T) {
 	type fields struct {
+		Left  Expression
 		Index Expression
 	}
 	tests := []struct {
@@ -315,43 +316,48 @@ func TestIndexExpression_String(t *testing.T) {
 		{
 			name: "simple integer",
 			fields: fields{
+				Left:  VariableExpression{Name: "a"},
 				Index: IntegerLiteral{Value: 0},
 			},
-			want: "[0]",
+			want: "$a[0]",
 		},
 		{
 			name: "simple string",
 			fields: fields{
+				Left:  VariableExpression{Name: "a"},
 				Index: StringLiteral{Value: "test"},
 			},
-			want: "['test']",
+			want: "$a['test']",
 		},
 		{
 			name: "arithmetic expression",
 			fields: fields{
+				Left:  VariableExpression{Name: "a"},
 				Index: BinaryExpression{
 					Left:  IntegerLiteral{Value: 0},
 					Right: IntegerLiteral{Value: 1},
 					Op:    "+",
 				},
 			},
-			want: "[0 + 1]",
+			want: "$a[0 + 1]",
 		},
 		{
 			name: "complex expression",
 			fields: fields{
+				Left:  VariableExpression{Name: "a"},
 				Index: BinaryExpression{
 					Left:  VariableExpression{Name: "integerVar"},
 					Right: IntegerLiteral{Value: 1},
 					Op:    "-",
 				},
 			},
-			want: "[$integerVar - 1]",
+			want: "$a[$integerVar - 1]",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ie := IndexExpression{
+				Left:  tt.fields.Left,
 				Index: tt.fields.Index,
 			}
 			if got := ie.String(); got != tt.want {
@@ -395,7 +401,8 @@ func TestUnaryExpression_String(t *testing.T) {
 		fields fields
 		want   string
 	}{
-		// TODO: Add test cases.
+		{"prefix", fields{true, "++", &VariableExpression{Name: "i"}}, "++$i"},
+		{"postfix", fields{false, "--", &VariableExpression{Name: "i"}}, "$i--"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -497,13 +504,13 @@ func TestForEachExpression_String(t *testing.T) {
 			fields: fields{
 				Array: ArrayLiteral{
 					Elements: []Expression{
-						IntegerLiteral{1},
-						IntegerLiteral{2},
-						IntegerLiteral{3},
+						IntegerLiteral{Value: 1},
+						IntegerLiteral{Value: 2},
+						IntegerLiteral{Value: 3},
 					},
 				},
-				Key:   &VariableExpression{"key"},
-				Value: &VariableExpression{"value"},
+				Key:   &VariableExpression{Name: "key"},
+				Value: &VariableExpression{Name: "value"},
 				Block: &BlockStatement{
 					Statements: []Statement{
 						&ExpressionStatement{
@@ -522,8 +529,8 @@ func TestForEachExpression_String(t *testing.T) {
 		{
 			name: "without key",
 			fields: fields{
-				Array: &VariableExpression{"array"},
-				Value: &VariableExpression{"value"},
+				Array: &VariableExpression{Name: "array"},
+				Value: &VariableExpression{Name: "value"},
 				Block: &BlockStatement{
 					Statements: []Statement{
 						&ExpressionStatement{
@@ -733,8 +740,8 @@ func TestArg_String(t *testing.T) {
 
 func TestFunctionDeclarationExpression_String(t *testing.T) {
 	type fields struct {
-		Name       Identifier
-		Args       []Arg
+		Name       *Identifier
+		Args       []*Arg
 		ReturnType *Identifier
 		Block      *BlockStatement
 	}
@@ -746,8 +753,8 @@ func TestFunctionDeclarationExpression_String(t *testing.T) {
 		{
 			name: "simple function with args",
 			fields: fields{
-				Name:       Identifier{Value: "testFunction"},
-				Args:       []Arg{{Type: &Identifier{Value: "int"}, Name: VariableExpression{Name: "var"}}},
+				Name:       &Identifier{Value: "testFunction"},
+				Args:       []*Arg{{Type: &Identifier{Value: "int"}, Name: VariableExpression{Name: "var"}}},
 				ReturnType: &Identifier{Value: "ReturnType"},
 				Block: &BlockStatement{Statements: []Statement{
 					ExpressionStatement{
@@ -0,0 +1,587 @@
+package eval
+
+import (
+	phperror "github.com/pmukhin/gophp/error"
+	"github.com/pmukhin/gophp/object"
+	"github.com/pmukhin/gophp/parser"
+	"github.com/pmukhin/gophp/scanner"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// evalCode parses and evaluates code in a fresh context with all globals registered
+func evalCode(code string) (object.Context, error) {
+	input := []rune(code)
+	p := parser.New(scanner.New(input), phperror.NewFormatter("<test>", input))
+	program, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+	ctx := object.NewContext(nil)
+	object.RegisterGlobals(ctx)
+	_, err = New().Eval(program, ctx)
+
+	return ctx, err
+}
+
+// inspect returns a short representation of scalar objects
+func inspect(o object.Object) string {
+	switch v := o.(type) {
+	case *object.IntegerObject:
+		return strconv.FormatInt(v.Value, 10)
+	case *object.StringObject:
+		return "'" + v.Value + "'"
+	case *object.BooleanObject:
+		return strconv.FormatBool(v.Value)
+	case *object.NullObject:
+		return "null"
+	}
+	return "<" + o.Class().Name() + ">"
+}
+
+func checkContextVariable(t *testing.T, ctx object.Context, name string, expected string) {
+	t.Helper()
+	v, err := ctx.GetContextVar(name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got := inspect(v); got != expected {
+		t.Errorf("$%s is %s, not %s", name, got, expected)
+	}
+}
+
+func TestEval_UserClass(t *testing.T) {
+	ctx, err := evalCode(`
+		class Counter {
+			public $count = 0
+			private $name
+
+			public function __construct($name) {
+				$this->name = $name
+			}
+
+			public function inc() {
+				$this->count = $this->count + 1
+				$this
+			}
+
+			public function name() { $this->name }
+		}
+
+		$first = new Counter("first")
+		$first->inc()->inc()
+		$second = new Counter("second")
+
+		$firstCount = $first->count
+		$secondCount = $second->count
+		$name = $first->name()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "firstCount", "2")
+	checkContextVariable(t, ctx, "secondCount", "0")
+	checkContextVariable(t, ctx, "name", "'first'")
+}
+
+func TestEval_UserClass_NoConstructor(t *testing.T) {
+	ctx, err := evalCode(`
+		namespace main
+
+		class Dog {
+			public $sound = "bark"
+		}
+		$sound = (new Dog)->sound
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "sound", "'bark'")
+}
+
+func TestEval_ClassValues(t *testing.T) {
+	ctx, err := evalCode(`
+		class Foo {
+			public $items = [1]
+		}
+		class Bar {}
+		function describe(Foo $foo) { 1 }
+
+		$class = Bar
+		$matched = match ($class) { Foo => "foo", Bar => "bar" }
+		$same = Foo === Foo
+		$other = Foo == Bar
+		$typed = try { describe(Foo) } catch (TypeError $e) { $e->getMessage() }
+
+		$a = new Foo()
+		$b = new Foo()
+		$a->items[0] = 9
+		$defaults = $b->items[0]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "matched", "'bar'")
+	checkContextVariable(t, ctx, "same", "true")
+	checkContextVariable(t, ctx, "other", "false")
+	checkContextVariable(t, ctx, "typed", "'describe(): argument #1 ($foo) must be of type Foo, Class given'")
+	checkContextVariable(t, ctx, "defaults", "1")
+}
+
+func TestEval_InternalClass(t *testing.T) {
+	ctx, err := evalCode(`
+		$array = new Array("hello", "world")
+		$length = $array->length()
+		$first = $array[0]
+		$int = new Int("42")
+		$boolean = try { new Boolean() } catch (Error $e) { $e->getMessage() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "first", "'hello'")
+	checkContextVariable(t, ctx, "length", "2")
+	checkContextVariable(t, ctx, "int", "42")
+	checkContextVariable(t, ctx, "boolean", "'can not instantiate internal class Boolean'")
+}
+
+func TestEval_UserClass_UndefinedMethod(t *testing.T) {
+	_, err := evalCode(`
+		class Dog {}
+		(new Dog())->meow()
+	`)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestEval_UserClass_Inheritance(t *testing.T) {
+	ctx, err := evalCode(`
+		class Animal {
+			public $name
+			public $legs = 4
+			public function __construct($name) { $this->name = $name }
+			public function speak() { $this->name + " says " + $this->sound() }
+			public function sound() { "..." }
+		}
+		class Dog extends Animal {
+			public function sound() { "woof" }
+			public function speak() { "[" + parent::speak() + "]" }
+		}
+		class Puppy extends Dog {
+			public $legs = 3
+			public function __construct($name) { parent::__construct($name + " jr") }
+		}
+
+		$animal = (new Animal("cat"))->speak()
+		$dog = (new Dog("rex"))->speak()
+		$puppy = (new Puppy("rex"))->speak()
+		$legs = (new Puppy("rex"))->legs
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "animal", "'cat says ...'")
+	checkContextVariable(t, ctx, "dog", "'[rex says woof]'")
+	checkContextVariable(t, ctx, "puppy", "'[rex jr says woof]'")
+	checkContextVariable(t, ctx, "legs", "3")
+}
+
+func TestEval_UserClass_ExtendFinal(t *testing.T) {
+	_, err := evalCode(`
+		final class Animal {}
+		class Dog extends Animal {}
+	`)
+	if err == nil || !strings.Contains(err.Error(), "final class Animal") {
+		t.Errorf("expected final class error, got %v", err)
+	}
+}
+
+func TestEval_UserClass_ParentOutsideOfClass(t *testing.T) {
+	_, err := evalCode(`parent::speak()`)
+	if err == nil {
+		t.Error("expected an error")
+	}
+}
+
+func TestEval_UserClass_Static(t *testing.T) {
+	ctx, err := evalCode(`
+		class Model {
+			const TABLE = "models"
+			const PREFIX = self::TABLE + "_"
+			public static $count = 0
+
+			public static function create() {
+				static::$count = static::$count + 1
+				new static
+			}
+			public static function table() { static::TABLE }
+			public function prefix() { self::PREFIX }
+		}
+		class User extends Model {
+			const TABLE = "users"
+		}
+
+		$user = User::create()
+		Model::create()
+		$users = User::table()
+		$models = Model::table()
+		$prefix = $user->prefix()
+		$count = User::$count
+		$instance = $user->table()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "users", "'users'")
+	checkContextVariable(t, ctx, "models", "'models'")
+	checkContextVariable(t, ctx, "prefix", "'models_'")
+	checkContextVariable(t, ctx, "count", "2")
+	checkContextVariable(t, ctx, "instance", "'users'")
+}
+
+func TestEval_UserClass_KeywordNames(t *testing.T) {
+	ctx, err := evalCode(`
+		class Router {
+			public $default = "home"
+			public function match($path) { $path }
+			public static function for($name) { $name }
+		}
+
+		$router = new Router()
+		$matched = $router->match("/")
+		$default = $router->default
+		$nullsafe = $router?->match("/x")
+		$static = Router::for("api")
+		$and = true
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "matched", "'/'")
+	checkContextVariable(t, ctx, "default", "'home'")
+	checkContextVariable(t, ctx, "nullsafe", "'/x'")
+	checkContextVariable(t, ctx, "static", "'api'")
+	checkContextVariable(t, ctx, "and", "true")
+}
+
+func TestEval_UserClass_Static_Errors(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`class A {}
+		A::B`, "constant A::B is not defined"},
+		{`class A {}
+		A::$b = 1
+		`, "static property A::$b is not declared"},
+		{`class A { public function f() {} }
+		A::f()`, "non-static method A::f can not be called statically"},
+		{`class A { public function f() {} }
+		class B { public function g() { return A::f() } }
+		(new B())->g()`, "non-static method A::f can not be called statically"},
+		{`self::f()`, "can not use self:: outside of a class"},
+		{`class A {
+			const B = 1
+			const B = 2
+		}`, "can not redeclare constant A::B"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestEval_UserClass_ErrorClasses(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`class Foo {}
+		Foo()`, "uncaught Error: Foo is not callable"},
+		{`$x = null
+		$x->y`, "uncaught TypeError: can not fetch property y of Null"},
+		{`$x = 1
+		$x->y = 2`, "uncaught TypeError: can not assign property y of Int"},
+		{`class A {}
+		(new A())->b`, "uncaught UndefinedNameError: property b is not defined in class A"},
+		{`class A {}
+		class A {}`, "uncaught Error: can not redeclare class A"},
+		{`function f() {}
+		function f() {}`, "uncaught Error: can not redeclare function f"},
+		{`function g() {}
+		g::f()`, "uncaught TypeError: g is not a class"},
+		{`class A {}
+		A::f()`, "uncaught UndefinedNameError: method f is not found in class A"},
+		{`class A {}
+		A::B`, "uncaught UndefinedNameError: constant A::B is not defined"},
+		{`parent::f()`, "uncaught Error: can not use parent:: outside of a class"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestEval_UserClass_Visibility(t *testing.T) {
+	ctx, err := evalCode(`
+		class Account {
+			private $balance = 0
+			protected static $count = 0
+
+			public function deposit($amount) {
+				$this->balance = $this->add($amount)
+				self::$count = self::$count + 1
+			}
+			public function balance() { $this->balance }
+			private function add($amount) { $this->balance + $amount }
+			protected function secret() { "secret" }
+		}
+		class Savings extends Account {
+			public function reveal() { $this->secret() }
+			public static function count() { parent::$count }
+		}
+
+		$account = new Savings()
+		$account->deposit(10)
+		$balance = $account->balance()
+		$secret = $account->reveal()
+		$count = Savings::count()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "balance", "10")
+	checkContextVariable(t, ctx, "secret", "'secret'")
+	checkContextVariable(t, ctx, "count", "1")
+}
+
+func TestEval_UserClass_Visibility_Errors(t *testing.T) {
+	class := `
+		class A {
+			private $secret = 1
+			protected $shared = 2
+			private function hidden() {}
+			protected function guarded() {}
+			private static function create() {}
+			private function __construct() {}
+			public static function make() { new A() }
+		}
+		class B extends A {
+			public function __construct() {}
+			public function peek() { $this->secret }
+			public function call() { $this->hidden() }
+		}
+		class C {
+			public function guarded() { A::make()->guarded() }
+		}
+	`
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`A::make()->secret`, "can not access private property A::$secret from global scope"},
+		{`A::make()->shared = 3
+		`, "can not access protected property A::$shared from global scope"},
+		{`A::make()->hidden()`, "can not access private method A::hidden from global scope"},
+		{`A::create()`, "can not access private method A::create from global scope"},
+		{`new A()`, "can not access private method A::__construct from global scope"},
+		{`(new B())->peek()`, "can not access private property A::$secret from scope B"},
+		{`(new B())->call()`, "can not access private method A::hidden from scope B"},
+		{`(new C())->guarded()`, "can not access protected method A::guarded from scope C"},
+		{`try { A::make()->secret } catch (AccessError $e) { throw $e }`,
+			"can not access private property A::$secret from global scope"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(class + tt.code)
+		if err == nil || !strings.Contains(err.Error(), "uncaught AccessError: "+tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestEval_Interface(t *testing.T) {
+	ctx, err := evalCode(`
+		interface HasName { public function name() }
+		interface Shape extends HasName {
+			public function area(Int $scale)
+		}
+		abstract class Polygon implements Shape {
+			public function name() { "polygon" }
+			abstract public function sides()
+		}
+		class Square extends Polygon {
+			public function area(Int $scale) { 4 * $scale }
+			public function sides() { 4 }
+		}
+		$square = new Square
+		$name = $square->name()
+		$area = $square->area(2)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "name", "'polygon'")
+	checkContextVariable(t, ctx, "area", "8")
+}
+
+func TestEval_Interface_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		err  string
+	}{
+		{
+			name: "missing method",
+			code: `interface I { public function f($a) }
+				class C implements I {}`,
+			err: "class C must implement method I::f",
+		},
+		{
+			name: "incompatible signature",
+			code: `interface I { public function f(Int $a) }
+				class C implements I { public function f(String $a) {} }`,
+			err: "declaration of C::f(String $a) must be compatible with I::f(Int $a)",
+		},
+		{
+			name: "incompatible return type",
+			code: `interface I { public function f(): Int }
+				class C implements I { public function f(): String {} }`,
+			err: "declaration of C::f(): String must be compatible with I::f(): Int",
+		},
+		{
+			name: "missing return type",
+			code: `interface I { public function f(): Int }
+				class C implements I { public function f() {} }`,
+			err: "declaration of C::f() must be compatible with I::f(): Int",
+		},
+		{
+			name: "non public implementation",
+			code: `interface I { public function f() }
+				class C implements I { private function f() {} }`,
+			err: "C::f must be public",
+		},
+		{
+			name: "abstract method left",
+			code: `abstract class A { abstract public function f() }
+				class B extends A {}`,
+			err: "class B must implement abstract method A::f",
+		},
+		{
+			name: "new abstract class",
+			code: `abstract class A {}
+				new A()`,
+			err: "can not instantiate abstract class A",
+		},
+		{
+			name: "new interface",
+			code: `interface I {}
+				new I()`,
+			err: "can not instantiate interface I",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := evalCode(tt.code)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("expected error %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestEval_DeclarationErrorClasses(t *testing.T) {
+	tests := []struct {
+		code  string
+		catch string
+	}{
+		{`function f() {}
+		class A extends f {}`, "TypeError"},
+		{`final class A {}
+		class B extends A {}`, "Error"},
+		{`function f() {}
+		class A implements f {}`, "TypeError"},
+		{`interface I { public function f() }
+		class A implements I {}`, "Error"},
+		{`interface I { public function f(Int $a) }
+		class A implements I { public function f(String $a) {} }`, "Error"},
+		{`function f() {}
+		class A { use f }`, "TypeError"},
+		{`trait T {}
+		class A { use T { T::f insteadof T } }`, "UndefinedNameError"},
+	}
+	for _, tt := range tests {
+		ctx, err := evalCode(`$caught = try { ` + tt.code + ` } catch (` + tt.catch + ` $e) { "caught" }`)
+		if err != nil {
+			t.Errorf("expected %s to be caught, got %v", tt.catch, err)
+			continue
+		}
+		checkContextVariable(t, ctx, "caught", "'caught'")
+	}
+}
+
+func TestEval_Traits(t *testing.T) {
+	ctx, err := evalCode(`
+		trait Logger {
+			public $log = "log:"
+			public function log($msg) {
+				$this->log = $this->log + " " + $msg
+				$this
+			}
+			public function say() { "logger" }
+		}
+		trait Greeter {
+			public function say() { "hello from " + $this->name }
+		}
+		class User {
+			use Logger, Greeter {
+				Greeter::say insteadof Logger
+				Logger::say as protected loggerSay
+			}
+			public $name = "bob"
+			public function both() { $this->say() + " / " + $this->loggerSay() }
+		}
+
+		$user = new User
+		$log = $user->log("a")->log("b")->log
+		$both = $user->both()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "log", "'log: a b'")
+	checkContextVariable(t, ctx, "both", "'hello from bob / logger'")
+}
+
+func TestEval_Traits_ClassOverrides(t *testing.T) {
+	ctx, err := evalCode(`
+		trait First { public function f() { 1 } }
+		trait Second { public function f() { 2 } }
+		class C {
+			use First, Second
+			public function f() { 3 }
+		}
+		$f = (new C)->f()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "f", "3")
+}
+
+func TestEval_Traits_Conflict(t *testing.T) {
+	_, err := evalCode(`
+		trait First { public function f() { 1 } }
+		trait Second { public function f() { 2 } }
+		class C { use First, Second }
+	`)
+	if err == nil || !strings.Contains(err.Error(), "method f is provided by traits First and Second") {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
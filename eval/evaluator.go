@@ -3,21 +3,12 @@ package eval
 import (
 	"errors"
 	"fmt"
-	"github.com/golang-collections/collections/stack"
 	"github.com/pmukhin/gophp/ast"
 	"github.com/pmukhin/gophp/object"
+	"strconv"
 	"strings"
 )
 
-type exceptionError struct {
-	class   object.Object
-	message string
-}
-
-func (e exceptionError) Error() string {
-	return e.message
-}
-
 type stateType struct {
 	namespace    string
 	namespaceSet bool
@@ -42,21 +33,47 @@ func newState() *stateType {
 }
 
 var opMethods = map[string]string{
-	"+": "__add",
-	"-": "__sub",
-	"/": "__div",
-	"*": "__mul",
-	"%": "__mod",
+	"+":  "__add",
+	"-":  "__sub",
+	"/":  "__div",
+	"*":  "__mul",
+	"%":  "__mod",
+	"**": "__pow",
+
+	"==": "__equal",
+
+	"&":  "__and",
+	"|":  "__or",
+	"^":  "__xor",
+	"<<": "__shl",
+	">>": "__shr",
+}
+
+// bitwiseOps take Booleans as 0 and 1
+var bitwiseOps = map[string]bool{"&": true, "|": true, "^": true, "<<": true, ">>": true}
 
-	"==":  "__equal",
-	"===": "__identical",
-	">":   "__gt",
-	"<":   "__lt",
-	">=":  "__gte",
-	"=<":  "__lte",
+// comparisons derive relational operators from the result of `__compare`
+var comparisons = map[string]func(int64) bool{
+	"<":  func(c int64) bool { return c < 0 },
+	">":  func(c int64) bool { return c > 0 },
+	"<=": func(c int64) bool { return c <= 0 },
+	">=": func(c int64) bool { return c >= 0 },
+}
+
+// logicalOps are boolean operators, word forms differ from symbol ones in precedence only
+var logicalOps = map[string]string{
+	"&&":  "&&",
+	"||":  "||",
+	"and": "&&",
+	"or":  "||",
+	"xor": "xor",
+}
 
-	"&": "__and",
-	"|": "__or",
+// unaryMethods maps prefix operators to methods implementing them
+var unaryMethods = map[string]string{
+	"-": "__neg",
+	"!": "__not",
+	"~": "__bitnot",
 }
 
 // Evaluator ...
@@ -66,14 +83,14 @@ type Evaluator interface {
 
 func New() Evaluator {
 	ev := new(evaluator)
-	ev.stack = stack.New()
 	ev.state = newState()
 
 	return ev
 }
 
 type evaluator struct {
-	stack *stack.Stack
+	// names of functions and methods being called
+	stack []string
 	state *stateType
 }
 
@@ -86,67 +103,331 @@ func (ev *evaluator) evalBlock(ctx object.Context, node *ast.BlockStatement) (ob
 		if err != nil {
 			return returnObject{value: object.Null}, err
 		}
-		// if it's return
-		if ret, ok := r.(returnObject); ok {
-			return ret, nil
+		// if it's return, break or continue
+		if unwinds(r) {
+			return r, nil
 		}
 		// add
 		ret = r
 	}
 
 	if ret == nil {
-		ret = returnObject{value: object.Null}
+		ret = object.Null
 	}
 
 	return ret, nil
 }
 
-func (ev *evaluator) evalRegisteredFunc(name *ast.Identifier, callArgs []ast.Expression, ctx object.Context) (object.Object, error) {
-	callName := name.Value
-	if use, ok := ev.state.uses[callName]; ok {
-		callName = use
+// lookup resolves name through `use` directives and the current namespace
+func (ev *evaluator) lookup(name string, ctx object.Context) (object.Object, error) {
+	if use, ok := ev.state.uses[name]; ok {
+		name = use
 	}
+	if ev.state.namespace != "" {
+		if o, err := ctx.GetGlobal(object.FullyQ(ev.state.namespace, name)); err == nil {
+			return o, nil
+		}
+	}
+	return ctx.GetGlobal(name)
+}
 
-	fun, err := ctx.GetGlobal(callName)
+func (ev *evaluator) evalRegisteredFunc(name *ast.Identifier, callArgs []ast.Expression, ctx object.Context) (object.Object, error) {
+	fun, err := ev.lookup(name.Value, ctx)
 	if err != nil {
 		return nil, err
 	}
-	args := make([]object.Object, len(callArgs))
-	for i, a := range callArgs {
-		args[i], err = ev.Eval(a, ctx)
-		if err != nil {
-			return nil, err
-		}
+	args, err := ev.evalArgs(callArgs, functionSignature(fun), ctx)
+	if err != nil {
+		return nil, err
 	}
 	switch realFun := fun.(type) {
 	case *object.InternalFunction:
 		return realFun.Call(args...)
 	case *object.UserFunction:
-		funCtx, e := ev.injectArgs(ctx, callArgs, realFun)
-		if e != nil {
-			return object.Null, e
-		}
-		return ev.Eval(realFun.Block(), funCtx)
+		return ev.invoke(ev.newCallContext(ctx, realFun), name.Value, realFun, args)
 	default:
-		panic("unexpected function type")
+		return nil, object.Throw(object.ErrorClass, "%s is not callable", name.Value)
 	}
 }
 
-func (ev *evaluator) injectArgs(ctx object.Context, callArgs []ast.Expression, fun object.FunctionObject) (object.Context, error) {
-	var err error
-	args := make([]object.Object, len(callArgs))
-	for i, a := range callArgs {
-		args[i], err = ev.Eval(a, ctx)
+// functionSignature returns parameters of a user function, built-in ones have none
+func functionSignature(fun object.Object) []*ast.Arg {
+	if f, ok := fun.(object.FunctionObject); ok {
+		return f.Args()
+	}
+	return nil
+}
+
+// methodSignature returns parameters of a user method or a built-in one declaring them
+func methodSignature(method object.Method) []*ast.Arg {
+	switch m := method.(type) {
+	case *object.UserMethod:
+		return m.Function().Args()
+	case interface{ Args() []*ast.Arg }:
+		return m.Args()
+	}
+	return nil
+}
+
+// evalMethodArgs evaluates call arguments of method, parameters of a built-in method
+// skipped by named arguments get their default values here as it has no context to evaluate them
+func (ev *evaluator) evalMethodArgs(callArgs []ast.Expression, method object.Method, ctx object.Context) ([]object.Object, error) {
+	signature := methodSignature(method)
+	args, err := ev.evalArgs(callArgs, signature, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := method.(*object.UserMethod); ok {
+		return args, nil
+	}
+	for i, arg := range args {
+		if arg != (skippedArg{}) {
+			continue
+		}
+		if signature[i].DefaultValue == nil {
+			return nil, object.Throw(object.ArgumentCountErrorClass, "argument #%d (%s) not passed", i+1, signature[i].Name.String())
+		}
+		if args[i], err = ev.Eval(signature[i].DefaultValue, ctx); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+// evalArgs evaluates call arguments from left to right, `...$array` is unpacked into values of the array.
+// Named arguments are put in place of the parameters of signature they name
+func (ev *evaluator) evalArgs(callArgs []ast.Expression, signature []*ast.Arg, ctx object.Context) ([]object.Object, error) {
+	args := make([]object.Object, 0, len(callArgs))
+	for _, a := range callArgs {
+		if named, ok := a.(*ast.NamedArgument); ok {
+			value, err := ev.Eval(named.Value, ctx)
+			if err != nil {
+				return nil, err
+			}
+			if args, err = placeNamedArg(args, named.Name.Value, value, signature); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		spread, isSpread := a.(*ast.SpreadExpression)
+		if isSpread {
+			a = spread.Value
+		}
+		arg, err := ev.Eval(a, ctx)
 		if err != nil {
 			return nil, err
 		}
+		if !isSpread {
+			args = append(args, arg)
+			continue
+		}
+		array, ok := arg.(*object.ArrayObject)
+		if !ok {
+			return nil, object.Throw(object.TypeErrorClass, "only arrays can be unpacked, %s given", arg.Class().Name())
+		}
+		args = append(args, array.Values...)
+	}
+	return args, nil
+}
+
+// placeNamedArg puts value at the position of parameter name, parameters
+// skipped before it are left for their default values
+func placeNamedArg(args []object.Object, name string, value object.Object, signature []*ast.Arg) ([]object.Object, error) {
+	for i, arg := range signature {
+		if arg.Name.Name != name || arg.Variadic {
+			continue
+		}
+		if i < len(args) && args[i] != (skippedArg{}) {
+			return nil, object.Throw(object.ErrorClass, "named argument $%s overwrites previous argument", name)
+		}
+		for len(args) <= i {
+			args = append(args, skippedArg{})
+		}
+		args[i] = value
+		return args, nil
+	}
+	return nil, object.Throw(object.ErrorClass, "unknown named argument $%s", name)
+}
+
+// newClosure makes an anonymous function capturing variables listed in `use`,
+// closures declared in methods also capture `$this` and the class of the method
+func (ev *evaluator) newClosure(node *ast.FunctionDeclarationExpression, ctx object.Context) object.FunctionObject {
+	scope := object.NewScope()
+	for _, bv := range node.BindVars {
+		scope.Capture(bv.Variable.Name, ctx.Scope(), bv.IsReference)
+	}
+	if ctx.ScopeClass() != nil {
+		scope.Capture("this", ctx.Scope(), false)
+	}
+	return object.NewAnonymousFunc(node.Args, node.ReturnType, node.Block, scope, ctx.ScopeClass(), ctx.CalledClass())
+}
+
+// newCallContext makes a context for a call of fun, it sees none of the caller's variables
+// but the ones fun has captured
+func (ev *evaluator) newCallContext(ctx object.Context, fun object.FunctionObject) object.Context {
+	closure, ok := fun.(*object.UserFunction)
+	if !ok {
+		return object.CloneContext(ctx, nil)
+	}
+	funCtx := object.CloneContext(ctx, closure.Scope())
+	funCtx.SetScopeClass(closure.ScopeClass())
+	funCtx.SetCalledClass(closure.CalledClass())
+
+	return funCtx
+}
+
+// invoke binds args in funCtx and evaluates the body of fun checking the type of returned value,
+// functions declared as void evaluate to null
+func (ev *evaluator) invoke(funCtx object.Context, name string, fun object.FunctionObject, args []object.Object) (object.Object, error) {
+	if err := ev.injectArgs(funCtx, name, args, fun); err != nil {
+		return object.Null, err
+	}
+	result, err := unpackReturnObject(ev.Eval(fun.Block(), funCtx))
+	if err != nil || fun.ReturnType() == nil {
+		return result, err
+	}
+	if fun.ReturnType().Value == "void" {
+		return object.Null, nil
+	}
+	matches, err := ev.isOfType(result, fun.ReturnType(), funCtx)
+	if err != nil {
+		return object.Null, err
+	}
+	if !matches {
+		return object.Null, object.Throw(object.TypeErrorClass, "%s(): return value must be of type %s, %s given",
+			name, fun.ReturnType().Value, result.Class().Name())
+	}
+	return result, nil
+}
+
+// injectArgs checks args against the signature of fun and sets them in funCtx,
+// omitted arguments take their default values and a variadic one collects the rest into an Array
+func (ev *evaluator) injectArgs(funCtx object.Context, name string, args []object.Object, fun object.FunctionObject) error {
+	if err := checkArgCount(name, args, fun.Args()); err != nil {
+		return err
 	}
-	funCtx := object.CloneContext(ctx, nil)
 	for i, definedArg := range fun.Args() {
-		funCtx.SetContextVar(definedArg.Name.Name, args[i])
+		var value object.Object = object.Null
+		if definedArg.Variadic {
+			var rest []object.Object
+			if i < len(args) {
+				rest = args[i:]
+			}
+			for j, arg := range rest {
+				if err := ev.checkArgType(name, i+j, definedArg, arg, funCtx); err != nil {
+					return err
+				}
+			}
+			value, _ = object.NewArray(rest...)
+		} else if i < len(args) && args[i] != (skippedArg{}) {
+			value = args[i]
+			if err := ev.checkArgType(name, i, definedArg, value, funCtx); err != nil {
+				return err
+			}
+			if !definedArg.IsReference {
+				value = object.CopyValue(value)
+			}
+		} else if definedArg.DefaultValue != nil {
+			// defaults may refer to preceding arguments
+			v, err := ev.Eval(definedArg.DefaultValue, funCtx)
+			if err != nil {
+				return err
+			}
+			value = v
+		} else if i < len(args) {
+			return object.Throw(object.ArgumentCountErrorClass, "%s(): argument #%d (%s) not passed",
+				name, i+1, definedArg.Name.String())
+		}
+		funCtx.SetContextVar(definedArg.Name.Name, value)
+	}
+
+	return nil
+}
+
+// checkArgType throws TypeError when value given for i-th argument of function name
+// doesn't match the declared type, `null` default makes the type nullable
+func (ev *evaluator) checkArgType(name string, i int, arg *ast.Arg, value object.Object, funCtx object.Context) error {
+	if arg.Type == nil || value == object.Null && isNullLiteral(arg.DefaultValue) {
+		return nil
+	}
+	matches, err := ev.isOfType(value, arg.Type, funCtx)
+	if err != nil || matches {
+		return err
+	}
+	return object.Throw(object.TypeErrorClass, "%s(): argument #%d (%s) must be of type %s, %s given",
+		name, i+1, arg.Name.String(), arg.Type.Value, value.Class().Name())
+}
+
+// scalarTypes maps lowercase type names to the built-in classes
+var scalarTypes = map[string]string{
+	"int":      "Int",
+	"string":   "String",
+	"bool":     "Boolean",
+	"array":    "Array",
+	"callable": "Function",
+	"null":     "Null",
+}
+
+// isOfType reports whether value is an instance of the type, names are resolved
+// as class names are, `mixed` accepts anything and `?Type` accepts null as well
+func (ev *evaluator) isOfType(value object.Object, typ *ast.Identifier, ctx object.Context) (bool, error) {
+	if typ.Value == "mixed" {
+		return true, nil
+	}
+	name := typ
+	if strings.HasPrefix(name.Value, "?") {
+		if value == object.Null {
+			return true, nil
+		}
+		name = &ast.Identifier{Token: typ.Token, Value: name.Value[1:]}
+	}
+	if builtin, ok := scalarTypes[name.Value]; ok {
+		name = &ast.Identifier{Token: typ.Token, Value: builtin}
+	}
+	class, err := ev.resolveClass(name, ctx)
+	if err != nil {
+		return false, object.Throw(object.TypeErrorClass, "%s is not a known type", typ.Value)
+	}
+	return object.IsInstanceOf(value.Class(), class), nil
+}
+
+// isNullLiteral reports whether e is the `null` constant
+func isNullLiteral(e ast.Expression) bool {
+	ident, ok := e.(*ast.Identifier)
+	return ok && ident.Value == "null"
+}
+
+// checkArgCount throws ArgumentCountError when args don't fit the signature of function name
+func checkArgCount(name string, args []object.Object, defined []*ast.Arg) error {
+	required, variadic := 0, false
+	for i, arg := range defined {
+		if arg.Variadic {
+			variadic = true
+		} else if arg.DefaultValue == nil {
+			required = i + 1
+		}
+	}
+	if len(args) >= required && (variadic || len(args) <= len(defined)) {
+		return nil
 	}
+	signature := make([]string, len(defined))
+	for i, arg := range defined {
+		signature[i] = arg.String()
+	}
+	if len(args) < required {
+		return object.Throw(object.ArgumentCountErrorClass, "too few arguments to %s(%s), %d given, %s %d expected",
+			name, strings.Join(signature, ", "), len(args), argCountQualifier("at least", required, defined), required)
+	}
+	return object.Throw(object.ArgumentCountErrorClass, "too many arguments to %s(%s), %d given, %s %d expected",
+		name, strings.Join(signature, ", "), len(args), argCountQualifier("at most", required, defined), len(defined))
+}
 
-	return funCtx, nil
+// argCountQualifier returns qualifier for functions with optional arguments and `exactly` for others
+func argCountQualifier(qualifier string, required int, defined []*ast.Arg) string {
+	if required == len(defined) {
+		return "exactly"
+	}
+	return qualifier
 }
 
 // unpackReturnObject ...
@@ -163,32 +444,158 @@ func (ev *evaluator) evalForeach(foreach *ast.ForEachExpression, ctx object.Cont
 	if err != nil {
 		return object.Null, err
 	}
-	for index, value := range array.(*object.ArrayObject).Values {
+	values, ok := array.(*object.ArrayObject)
+	if !ok {
+		return object.Null, object.Throw(object.TypeErrorClass, "foreach expects Array, %s given", array.Class().Name())
+	}
+	for index, value := range values.Values {
 		if foreach.Key != nil {
 			ctx.SetContextVar(foreach.Key.Name, &object.IntegerObject{Value: int64(index)})
 		}
 		ctx.SetContextVar(foreach.Value.Name, value)
-		_, err := ev.Eval(foreach.Block, ctx)
+		if stop, r, err := ev.evalLoopBody(foreach.Block, ctx); stop {
+			return r, err
+		}
+	}
+
+	return object.Null, nil
+}
+
+// evalCondition evaluates expression and converts it to Boolean
+func (ev *evaluator) evalCondition(expression ast.Expression, ctx object.Context) (bool, error) {
+	condition, err := ev.Eval(expression, ctx)
+	if err != nil {
+		return false, err
+	}
+	return object.ToBoolean(condition)
+}
+
+// evalWhile evaluates body while condition holds, the loop itself evaluates to null
+func (ev *evaluator) evalWhile(while *ast.WhileExpression, ctx object.Context) (object.Object, error) {
+	for {
+		condition, err := ev.evalCondition(while.Condition, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		if !condition {
+			return object.Null, nil
+		}
+		if stop, r, err := ev.evalLoopBody(while.Body, ctx); stop {
+			return r, err
+		}
+	}
+}
 
+// evalDoWhile evaluates body once and then while condition holds
+func (ev *evaluator) evalDoWhile(do *ast.DoWhileExpression, ctx object.Context) (object.Object, error) {
+	for {
+		if stop, r, err := ev.evalLoopBody(do.Body, ctx); stop {
+			return r, err
+		}
+		condition, err := ev.evalCondition(do.Condition, ctx)
 		if err != nil {
 			return object.Null, err
 		}
+		if !condition {
+			return object.Null, nil
+		}
 	}
+}
 
-	return object.Null, nil
+// evalFor evaluates init expressions once, then body and step expressions
+// while condition holds, a loop without condition runs until return
+func (ev *evaluator) evalFor(node *ast.ForExpression, ctx object.Context) (object.Object, error) {
+	if err := ev.evalExpressions(node.Init, ctx); err != nil {
+		return object.Null, err
+	}
+	for {
+		if node.Condition != nil {
+			condition, err := ev.evalCondition(node.Condition, ctx)
+			if err != nil {
+				return object.Null, err
+			}
+			if !condition {
+				return object.Null, nil
+			}
+		}
+		if stop, r, err := ev.evalLoopBody(node.Body, ctx); stop {
+			return r, err
+		}
+		if err := ev.evalExpressions(node.Inc, ctx); err != nil {
+			return object.Null, err
+		}
+	}
+}
+
+// evalLoopBody evaluates body of a loop and reports whether the loop must stop,
+// in that case the loop evaluates to r: null or what has to unwind outer blocks
+func (ev *evaluator) evalLoopBody(body *ast.BlockStatement, ctx object.Context) (stop bool, r object.Object, err error) {
+	r, err = ev.Eval(body, ctx)
+	if err != nil {
+		return true, object.Null, err
+	}
+	switch r := r.(type) {
+	case returnObject:
+		return true, r, nil
+	case loopControl:
+		if r.levels > 1 {
+			return true, loopControl{isContinue: r.isContinue, levels: r.levels - 1}, nil
+		}
+		if r.isContinue {
+			return false, nil, nil
+		}
+		return true, object.Null, nil
+	}
+	return false, nil, nil
+}
+
+// evalExpressions evaluates expressions from left to right dropping their values
+func (ev *evaluator) evalExpressions(expressions []ast.Expression, ctx object.Context) error {
+	for _, e := range expressions {
+		if _, err := ev.Eval(e, ctx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // registerFunc puts func into globals table
 func registerFunc(ctx object.Context, name string, fun object.FunctionObject) error {
-	return ctx.SetGlobal(name, fun)
+	return declareGlobal(ctx, "function", name, fun)
+}
+
+// declareGlobal puts value into globals table unless its name is already taken
+func declareGlobal(ctx object.Context, kind, name string, value object.Object) error {
+	if _, err := ctx.GetGlobal(name); err == nil {
+		return object.Throw(object.ErrorClass, "can not redeclare %s %s", kind, name)
+	}
+	return ctx.SetGlobal(name, value)
 }
 
 func (ev *evaluator) wrap(err error, node ast.Node) error {
-	stackTrace := make([]string, ev.stack.Len())
-	for i := 0; i < ev.stack.Len(); i++ {
-		stackTrace[i] = ev.stack.Pop().(string)
+	return fmt.Errorf("%s at %s", err.Error(), strings.Join(ev.trace(), "\n"))
+}
+
+// call evaluates f as a new frame of the call stack,
+// an exception thrown inside gets the stack as its trace
+func (ev *evaluator) call(frame string, f func() (object.Object, error)) (object.Object, error) {
+	ev.stack = append(ev.stack, frame)
+	defer func() { ev.stack = ev.stack[:len(ev.stack)-1] }()
+
+	o, err := f()
+	if thrown, ok := err.(*object.ThrowError); ok {
+		object.SetTrace(thrown.Exception, ev.trace())
+	}
+	return o, err
+}
+
+// trace returns frames of the call stack starting from the innermost one
+func (ev *evaluator) trace() []string {
+	frames := make([]string, len(ev.stack))
+	for i, frame := range ev.stack {
+		frames[len(ev.stack)-1-i] = frame
 	}
-	return fmt.Errorf("%s at %s", err.Error(), strings.Join(stackTrace, "\n"))
+	return frames
 }
 
 // Eval ...
@@ -197,7 +604,12 @@ func (ev *evaluator) Eval(node ast.Node, ctx object.Context) (object.Object, err
 }
 
 func (ev *evaluator) evalFunctionCall(node *ast.FunctionCall, ctx object.Context) (object.Object, error) {
-	ev.stack.Push(fmt.Sprintf("%s", node.Target.String()))
+	return ev.call(node.Target.String(), func() (object.Object, error) {
+		return ev.doFunctionCall(node, ctx)
+	})
+}
+
+func (ev *evaluator) doFunctionCall(node *ast.FunctionCall, ctx object.Context) (object.Object, error) {
 	if funcName, ok := node.Target.(*ast.Identifier); ok {
 		return unpackReturnObject(ev.evalRegisteredFunc(funcName, node.CallArgs, ctx))
 	}
@@ -206,8 +618,15 @@ func (ev *evaluator) evalFunctionCall(node *ast.FunctionCall, ctx object.Context
 	if err != nil {
 		return object.Null, err
 	}
-	funCtx, err := ev.injectArgs(ctx, node.CallArgs, resolve.(object.FunctionObject))
-	return unpackReturnObject(ev.Eval(resolve.(object.FunctionObject).Block(), funCtx))
+	fun, ok := resolve.(object.FunctionObject)
+	if !ok {
+		return object.Null, object.Throw(object.TypeErrorClass, "%s is not callable", resolve.Class().Name())
+	}
+	args, err := ev.evalArgs(node.CallArgs, fun.Args(), ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	return ev.invoke(ev.newCallContext(ctx, fun), "{closure}", fun, args)
 }
 
 func (ev *evaluator) evalArray(node *ast.ArrayLiteral, ctx object.Context) (object.Object, error) {
@@ -217,7 +636,7 @@ func (ev *evaluator) evalArray(node *ast.ArrayLiteral, ctx object.Context) (obje
 		if err != nil {
 			return object.Null, err
 		}
-		values[i] = result
+		values[i] = object.CopyValue(result)
 	}
 	return object.NewArray(values...)
 }
@@ -245,44 +664,40 @@ func (ev *evaluator) doEval(node ast.Node, ctx object.Context) (object.Object, e
 		return returnObject{value: v}, nil
 	case *ast.FunctionDeclarationExpression:
 		if node.Anonymous == true {
-			return object.NewAnonymousFunc(node.Args, node.Block), nil
+			return ev.newClosure(node, ctx), nil
 		}
 		name := object.FullyQ(ev.state.namespace, node.Name.Value)
-		return object.Null, registerFunc(ctx, name, object.NewUserFunc(node.Args, node.Block))
+		return object.Null, registerFunc(ctx, name, object.NewUserFunc(node.Args, node.ReturnType, node.Block))
 	case *ast.FunctionCall:
 		return ev.evalFunctionCall(node, ctx)
 	case *ast.FetchExpression:
 		return ev.evalFetchExpression(node, ctx)
+	case *ast.StaticFetchExpression:
+		return ev.evalStaticFetchExpression(node, ctx)
 	case *ast.ClassDeclarationExpression:
 		return ev.registerUserClass(node, ctx)
+	case *ast.InterfaceDeclarationExpression:
+		return ev.registerInterface(node, ctx)
+	case *ast.TraitDeclarationExpression:
+		return ev.registerTrait(node, ctx)
 	case *ast.ConditionalExpression:
-		condition, err := ev.Eval(node.Condition, ctx)
+		condition, err := ev.evalCondition(node.Condition, ctx)
 		if err != nil {
 			return object.Null, err
 		}
-		var boolean *object.BooleanObject
-
-		b, isBool := condition.(*object.BooleanObject)
-		if !isBool {
-			toBoolean := condition.Class().Methods().Find("__toBoolean")
-			if toBoolean == nil {
-				return object.Null, fmt.Errorf("can not convert %v to Boolean", condition)
-			}
-			b, e := toBoolean.Call(condition)
-			if e != nil {
-				return object.Null, err
-			}
-			boolean = b.(*object.BooleanObject)
-		} else {
-			boolean = b
-		}
-		if boolean.Value {
+		if condition {
 			return ev.Eval(node.Consequence, ctx)
 		}
 		if node.Alternative != nil {
 			return ev.Eval(node.Alternative, ctx)
 		}
-		return object.Null, err
+		return object.Null, nil
+	case *ast.WhileExpression:
+		return ev.evalWhile(node, ctx)
+	case *ast.DoWhileExpression:
+		return ev.evalDoWhile(node, ctx)
+	case *ast.ForExpression:
+		return ev.evalFor(node, ctx)
 	case *ast.Identifier:
 		if node.Value == "true" {
 			return object.True, nil
@@ -290,9 +705,18 @@ func (ev *evaluator) doEval(node ast.Node, ctx object.Context) (object.Object, e
 		if node.Value == "false" {
 			return object.False, nil
 		}
+		if node.Value == "null" {
+			return object.Null, nil
+		}
 		// other constant?
 		return ctx.GetGlobal(node.Value)
 	case *ast.BinaryExpression:
+		if _, ok := logicalOps[node.Op]; ok {
+			return ev.evalLogicalExpression(node, ctx)
+		}
+		if node.Op == "??" {
+			return ev.evalCoalesce(node, ctx)
+		}
 		l, err := ev.Eval(node.Left, ctx)
 		if err != nil {
 			return nil, err
@@ -301,13 +725,18 @@ func (ev *evaluator) doEval(node ast.Node, ctx object.Context) (object.Object, e
 		if err != nil {
 			return nil, err
 		}
-		if m := l.Class().Methods().Find(opMethods[node.Op]); m != nil {
-			return m.Call(l, r)
-		}
-		return nil, fmt.Errorf("operator %s (method %s) is not defined on type %s",
-			node.Op, opMethods[node.Op], l.Class().Name())
+		return binaryOperation(node.Op, l, r)
 	case *ast.IndexExpression:
 		return ev.evalIndexExpression(node, ctx)
+	case *ast.UnaryExpression:
+		if node.Op == "++" || node.Op == "--" {
+			return ev.evalIncDec(node, ctx)
+		}
+		right, err := ev.Eval(node.Right, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return unaryOperation(node.Op, right)
 	case *ast.IntegerLiteral:
 		return object.IntegerClass.InternalConstructor(node.Value)
 	case *ast.StringLiteral:
@@ -321,10 +750,19 @@ func (ev *evaluator) doEval(node ast.Node, ctx object.Context) (object.Object, e
 	case *ast.Null:
 		return object.Null, nil
 	case *ast.AssignmentExpression:
+		if node.Operator != "" {
+			return ev.evalCompoundAssignment(node, ctx)
+		}
 		right, e := ev.Eval(node.Right, ctx)
 		if e != nil {
 			return nil, e
 		}
+		// right side left the block, e.g. try with continue in catch
+		if unwinds(right) {
+			return right, nil
+		}
+		// arrays are assigned by value
+		right = object.CopyValue(right)
 		switch left := node.Left.(type) {
 		case *ast.ConstantExpression:
 			e := ctx.SetGlobal(left.Name.Value, right)
@@ -332,9 +770,43 @@ func (ev *evaluator) doEval(node ast.Node, ctx object.Context) (object.Object, e
 		case *ast.VariableExpression:
 			e := ctx.SetContextVar(left.Name, right)
 			return right, e
+		case *ast.FetchExpression:
+			return right, ev.assignProperty(left, right, ctx)
+		case *ast.StaticFetchExpression:
+			return right, ev.assignStaticProperty(left, right, ctx)
+		case *ast.IndexExpression:
+			target, e := ev.indexTarget(left, ctx)
+			if e != nil {
+				return object.Null, e
+			}
+			return right, target.set(right)
 		}
 	case *ast.NewExpression:
 		return ev.evalConstructorCall(node, ctx)
+	case *ast.TryExpression:
+		return ev.evalTryExpression(node, ctx)
+	case *ast.InstanceOfExpression:
+		return ev.evalInstanceOf(node, ctx)
+	case *ast.MatchExpression:
+		return ev.evalMatchExpression(node, ctx)
+	case *ast.SwitchExpression:
+		return ev.evalSwitchExpression(node, ctx)
+	case *ast.SpreadExpression:
+		return object.Null, object.Throw(object.ErrorClass, "%s: arrays can only be unpacked into call arguments", node.String())
+	case *ast.BreakExpression:
+		return loopControl{levels: node.Levels}, nil
+	case *ast.ContinueExpression:
+		return loopControl{isContinue: true, levels: node.Levels}, nil
+	case *ast.ThrowExpression:
+		exception, err := ev.Eval(node.Value, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		if !object.IsThrowable(exception) {
+			return object.Null, object.Throw(object.ErrorClass, "can only throw objects implementing Throwable, %s given",
+				exception.Class().Name())
+		}
+		return object.Null, &object.ThrowError{Exception: exception.(*object.UserObject)}
 	case *ast.ExpressionStatement:
 		return ev.Eval(node.Expression, ctx)
 	case *ast.BlockStatement:
@@ -398,70 +870,1319 @@ func (ev *evaluator) unpackRange(ex *ast.RangeExpression, ctx object.Context) (o
 
 // evalFetchExpression ...
 func (ev *evaluator) evalFetchExpression(ex *ast.FetchExpression, ctx object.Context) (object.Object, error) {
-	obj, err := ev.Eval(ex.Left, ctx)
+	obj, err := ev.evalFetchChain(ex, ctx)
+	if err == errShortCircuit {
+		return object.Null, nil
+	}
+	return obj, err
+}
+
+// evalFetchChain evaluates a chain of `->` fetches, `?->` on null stops the whole
+// chain returning errShortCircuit so the rest of it is skipped
+func (ev *evaluator) evalFetchChain(ex *ast.FetchExpression, ctx object.Context) (object.Object, error) {
+	var (
+		obj object.Object
+		err error
+	)
+	if left, ok := ex.Left.(*ast.FetchExpression); ok {
+		obj, err = ev.evalFetchChain(left, ctx)
+	} else {
+		obj, err = ev.Eval(ex.Left, ctx)
+	}
 	if err != nil {
 		return object.Null, err
 	}
+	if ex.IsNullsafe && obj == object.Null {
+		return object.Null, errShortCircuit
+	}
 
 	switch r := ex.Right.(type) {
 	case *ast.FunctionCall:
-		return ev.evalMethodCall(obj, r, ctx)
+		return ev.evalMethodCall(obj.Class(), obj, r, ctx)
+	case *ast.Identifier:
+		return ev.evalPropertyFetch(obj, r, ctx)
 	default:
 		return object.Null, errors.New("unexpected right node")
 	}
 }
 
-// evalMethodCall ...
-func (ev *evaluator) evalMethodCall(obj object.Object, node *ast.FunctionCall, ctx object.Context) (object.Object, error) {
-	methodName, ok := node.Target.(*ast.Identifier)
-	if !ok {
-		return object.Null, errors.New("method name must be an Identifier")
-	}
-	method := obj.Class().Methods().Find(methodName.Value)
-	if method == nil {
-		return object.Null, fmt.Errorf("method %s is not found in class %s", methodName.Value, obj.Class().Name())
-	}
-	args := make([]object.Object, len(node.CallArgs))
-	for i, expr := range node.CallArgs {
-		obj, err := ev.Eval(expr, ctx)
-		if err != nil {
-			return object.Null, err
-		}
-		args[i] = obj
-	}
-	return method.Call(obj, args...)
-}
-
-// evalConstructorCall ...
-func (ev *evaluator) evalConstructorCall(node *ast.NewExpression, ctx object.Context) (object.Object, error) {
-	class, err := ctx.GetGlobal(node.ClassName.Value)
+// evalStaticFetchExpression evaluates `Class::method()`, `Class::CONSTANT` and `Class::$property`
+func (ev *evaluator) evalStaticFetchExpression(ex *ast.StaticFetchExpression, ctx object.Context) (object.Object, error) {
+	class, err := ev.resolveClass(ex.Left.(*ast.Identifier), ctx)
 	if err != nil {
 		return object.Null, err
 	}
-	switch class := class.(type) {
-	case *object.InternalClass:
-		fmt.Println(*class)
-		panic("not implemented")
+
+	switch r := ex.Right.(type) {
+	case *ast.FunctionCall:
+		return ev.evalStaticMethodCall(class, isRelativeClass(ex.Left), r, ctx)
+	case *ast.Identifier:
+		return classConstant(class, r.Value)
+	case *ast.VariableExpression:
+		return staticProperty(class, r.Name, ctx)
 	default:
-		return object.Null, fmt.Errorf("%s is not a class but %s", node.ClassName.Value, class.Class().Name())
+		return object.Null, errors.New("unexpected right node")
 	}
-	panic("ad")
 }
 
-// evalIndexExpression ...
-func (ev *evaluator) evalIndexExpression(node *ast.IndexExpression, ctx object.Context) (object.Object, error) {
-	l, err := ev.Eval(node.Left, ctx)
+// resolveClass looks up a class by name, `self`, `static` and `parent`
+// are resolved against the class which method is being evaluated
+func (ev *evaluator) resolveClass(name *ast.Identifier, ctx object.Context) (object.Class, error) {
+	if isRelativeClass(name) {
+		scope := ctx.ScopeClass()
+		if scope == nil {
+			return nil, object.Throw(object.ErrorClass, "can not use %s:: outside of a class", name.Value)
+		}
+		switch name.Value {
+		case "static":
+			return ctx.CalledClass(), nil
+		case "parent":
+			parent := scope.SuperClass()
+			if parent == nil {
+				return nil, object.Throw(object.ErrorClass, "can not use parent:: in class %s as it has no parent", scope.Name())
+			}
+			return parent, nil
+		}
+		return scope, nil
+	}
+	o, err := ev.lookup(name.Value, ctx)
 	if err != nil {
 		return nil, err
 	}
-	index, err := ev.Eval(node.Index, ctx)
-	if i := l.Class().Methods().Find("__index"); i != nil {
-		return i.Call(l, index)
+	class, ok := o.(object.Class)
+	if !ok {
+		return nil, object.Throw(object.TypeErrorClass, "%s is not a class", name.Value)
 	}
-	return object.Null, fmt.Errorf("%v does not support indexing", l.Class().Name())
+	return class, nil
 }
 
-func (ev *evaluator) registerUserClass(cde *ast.ClassDeclarationExpression, ctx object.Context) (object.Object, error) {
-	_ = cde.Name.Value
-	panic("")
+// isRelativeClass reports whether node is one of `self`, `static` and `parent`
+func isRelativeClass(node ast.Expression) bool {
+	ident, ok := node.(*ast.Identifier)
+	if !ok {
+		return false
+	}
+	return ident.Value == "self" || ident.Value == "static" || ident.Value == "parent"
+}
+
+// evalStaticMethodCall calls a static method of class, calls through `self::`, `parent::`
+// and `static::` keep the called class. Instance methods can be called this way
+// only with `$this` in the scope, e.g. `parent::__construct()`
+func (ev *evaluator) evalStaticMethodCall(class object.Class, forward bool, node *ast.FunctionCall, ctx object.Context) (object.Object, error) {
+	methodName, ok := node.Target.(*ast.Identifier)
+	if !ok {
+		return object.Null, errors.New("method name must be an Identifier")
+	}
+	if method := class.StaticMethods().Find(methodName.Value); method != nil {
+		if err := checkMethodAccess(class, methodName.Value, method, ctx); err != nil {
+			return object.Null, err
+		}
+		args, err := ev.evalMethodArgs(node.CallArgs, method, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		calledClass := class
+		if forward && ctx.CalledClass() != nil {
+			calledClass = ctx.CalledClass()
+		}
+		return method.Call(calledClass, args...)
+	}
+	if method := class.Methods().Find(methodName.Value); method != nil {
+		this, err := ctx.GetContextVar("this")
+		if err != nil {
+			return object.Null, err
+		}
+		if this == object.Null || !object.IsInstanceOf(this.Class(), class) {
+			return object.Null, object.Throw(object.ErrorClass, "non-static method %s::%s can not be called statically", class.Name(), methodName.Value)
+		}
+		if err := checkMethodAccess(class, methodName.Value, method, ctx); err != nil {
+			return object.Null, err
+		}
+		args, err := ev.evalMethodArgs(node.CallArgs, method, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		return method.Call(this, args...)
+	}
+	return object.Null, object.Throw(object.UndefinedNameErrorClass, "method %s is not found in class %s", methodName.Value, class.Name())
+}
+
+// classConstant returns the value of constant name of class
+func classConstant(class object.Class, name string) (object.Object, error) {
+	if userClass, ok := class.(*object.UserClass); ok {
+		if value, ok := userClass.Constant(name); ok {
+			return value, nil
+		}
+	}
+	return object.Null, object.Throw(object.UndefinedNameErrorClass, "constant %s::%s is not defined", class.Name(), name)
+}
+
+// staticProperty returns the value of static property name of class
+func staticProperty(class object.Class, name string, ctx object.Context) (object.Object, error) {
+	if userClass, ok := class.(*object.UserClass); ok {
+		if value, ok := userClass.StaticProperty(name); ok {
+			return value, checkPropertyAccess(userClass, name, ctx)
+		}
+	}
+	return object.Null, object.Throw(object.UndefinedNameErrorClass, "static property %s::$%s is not declared", class.Name(), name)
+}
+
+// assignStaticProperty handles `Class::$property = $value`
+func (ev *evaluator) assignStaticProperty(fetch *ast.StaticFetchExpression, value object.Object, ctx object.Context) error {
+	class, err := ev.resolveClass(fetch.Left.(*ast.Identifier), ctx)
+	if err != nil {
+		return err
+	}
+	return setStaticProperty(class, fetch.Right.(*ast.VariableExpression).Name, value, ctx)
+}
+
+// setStaticProperty assigns static property name of class checking its visibility
+func setStaticProperty(class object.Class, name string, value object.Object, ctx object.Context) error {
+	if userClass, ok := class.(*object.UserClass); ok {
+		if _, declared := userClass.StaticProperty(name); declared {
+			if err := checkPropertyAccess(userClass, name, ctx); err != nil {
+				return err
+			}
+			userClass.SetStaticProperty(name, value)
+			return nil
+		}
+	}
+	return object.Throw(object.UndefinedNameErrorClass, "static property %s::$%s is not declared", class.Name(), name)
+}
+
+// evalMethodCall ...
+func (ev *evaluator) evalMethodCall(class object.Class, obj object.Object, node *ast.FunctionCall, ctx object.Context) (object.Object, error) {
+	methodName, ok := node.Target.(*ast.Identifier)
+	if !ok {
+		return object.Null, errors.New("method name must be an Identifier")
+	}
+	method := class.Methods().Find(methodName.Value)
+	if method == nil {
+		// static methods can be called on instances too
+		if class.StaticMethods().Find(methodName.Value) != nil {
+			return ev.evalStaticMethodCall(class, false, node, ctx)
+		}
+		return object.Null, object.Throw(object.UndefinedNameErrorClass, "method %s is not found in class %s", methodName.Value, class.Name())
+	}
+	if err := checkMethodAccess(class, methodName.Value, method, ctx); err != nil {
+		return object.Null, err
+	}
+	args, err := ev.evalMethodArgs(node.CallArgs, method, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	return method.Call(obj, args...)
+}
+
+// checkMethodAccess returns an error if method of class is not visible from the scope of ctx
+func checkMethodAccess(class object.Class, name string, method object.Method, ctx object.Context) error {
+	declaring := class
+	if m, ok := method.(*object.UserMethod); ok {
+		declaring = m.DeclaringClass()
+	}
+	return checkAccess("method", declaring.Name()+"::"+name, declaring, method.Visibility(), ctx)
+}
+
+// checkPropertyAccess returns an error if property of class is not visible from the scope of ctx
+func checkPropertyAccess(class *object.UserClass, name string, ctx object.Context) error {
+	vis, declaring := class.PropertyVisibility(name)
+	return checkAccess("property", declaring.Name()+"::$"+name, declaring, vis, ctx)
+}
+
+// checkAccess checks a member declared in class against the scope of ctx: private members are
+// visible in the declaring class only, protected ones in the classes of the same hierarchy
+func checkAccess(kind, member string, declaring object.Class, vis object.Visibility, ctx object.Context) error {
+	scope := ctx.ScopeClass()
+	switch {
+	case vis == object.VisibilityPublic:
+		return nil
+	case scope == nil:
+		return object.Throw(object.AccessErrorClass, "can not access %s %s %s from global scope", vis, kind, member)
+	case vis == object.VisibilityPrivate && scope == declaring:
+		return nil
+	case vis == object.VisibilityProtected && (object.IsSubclassOf(scope, declaring) || object.IsSubclassOf(declaring, scope)):
+		return nil
+	}
+	return object.Throw(object.AccessErrorClass, "can not access %s %s %s from scope %s", vis, kind, member, scope.Name())
+}
+
+// evalPropertyFetch ...
+func (ev *evaluator) evalPropertyFetch(obj object.Object, name *ast.Identifier, ctx object.Context) (object.Object, error) {
+	instance, ok := obj.(*object.UserObject)
+	if !ok {
+		return object.Null, object.Throw(object.TypeErrorClass, "can not fetch property %s of %s", name.Value, obj.Class().Name())
+	}
+	value, ok := instance.Property(name.Value)
+	if !ok {
+		return object.Null, object.Throw(object.UndefinedNameErrorClass, "property %s is not defined in class %s", name.Value, obj.Class().Name())
+	}
+	return value, checkPropertyAccess(instance.Class().(*object.UserClass), name.Value, ctx)
+}
+
+// assignProperty handles `$object->property = $value`
+func (ev *evaluator) assignProperty(fetch *ast.FetchExpression, value object.Object, ctx object.Context) error {
+	obj, err := ev.Eval(fetch.Left, ctx)
+	if err != nil {
+		return err
+	}
+	return setProperty(obj, fetch.Right.(*ast.Identifier).Value, value, ctx)
+}
+
+// setProperty assigns property name of obj checking its visibility
+func setProperty(obj object.Object, name string, value object.Object, ctx object.Context) error {
+	instance, ok := obj.(*object.UserObject)
+	if !ok {
+		return object.Throw(object.TypeErrorClass, "can not assign property %s of %s", name, obj.Class().Name())
+	}
+	if err := checkPropertyAccess(instance.Class().(*object.UserClass), name, ctx); err != nil {
+		return err
+	}
+	instance.SetProperty(name, value)
+	return nil
+}
+
+// binaryOperation dispatches operator op to the method of l it's mapped to in opMethods,
+// `.` is a concatenation of both operands converted to String, comparisons go through `__compare`
+func binaryOperation(op string, l, r object.Object) (object.Object, error) {
+	switch op {
+	case "==", "!=":
+		equal, err := looselyEqual(l, r)
+		return object.NativeBool(equal == (op == "==")), err
+	case "===", "!==":
+		same, err := identical(l, r)
+		return object.NativeBool(same == (op == "===")), err
+	case "<=>":
+		c, err := object.Compare(l, r)
+		if err != nil {
+			return object.Null, err
+		}
+		return &object.IntegerObject{Value: c}, nil
+	}
+	if holds, ok := comparisons[op]; ok {
+		c, err := object.Compare(l, r)
+		if err != nil {
+			return object.Null, err
+		}
+		return object.NativeBool(holds(c)), nil
+	}
+	if op == "." {
+		ls, err := object.ToString(l)
+		if err != nil {
+			return object.Null, err
+		}
+		rs, err := object.ToString(r)
+		if err != nil {
+			return object.Null, err
+		}
+		return &object.StringObject{Value: ls.Value + rs.Value}, nil
+	}
+	if _, ok := l.(*object.BooleanObject); ok && bitwiseOps[op] {
+		i, err := object.ToInteger(l)
+		if err != nil {
+			return object.Null, err
+		}
+		l = i
+	}
+	if m := l.Class().Methods().Find(opMethods[op]); m != nil {
+		return m.Call(l, r)
+	}
+	return nil, object.Throw(object.TypeErrorClass, "operator %s (method %s) is not defined on type %s",
+		op, opMethods[op], l.Class().Name())
+}
+
+// evalLogicalExpression evaluates `&&`, `||` and their word forms short-circuit,
+// operands are converted with `__toBoolean` like conditions are and the result is Boolean
+func (ev *evaluator) evalLogicalExpression(node *ast.BinaryExpression, ctx object.Context) (object.Object, error) {
+	l, err := ev.evalCondition(node.Left, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	op := logicalOps[node.Op]
+	if op == "&&" && !l || op == "||" && l {
+		return object.NativeBool(l), nil
+	}
+	r, err := ev.evalCondition(node.Right, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	if op == "xor" {
+		return object.NativeBool(l != r), nil
+	}
+	return object.NativeBool(r), nil
+}
+
+// evalCoalesce evaluates `$a ?? $b`, $b is evaluated only if $a is null or undefined
+func (ev *evaluator) evalCoalesce(node *ast.BinaryExpression, ctx object.Context) (object.Object, error) {
+	l, err := ev.evalIfDefined(node.Left, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	if l != object.Null {
+		return l, nil
+	}
+	return ev.Eval(node.Right, ctx)
+}
+
+// evalIfDefined evaluates node the way isset() does: undefined variables,
+// properties and indexes anywhere in the chain evaluate to null instead of failing
+func (ev *evaluator) evalIfDefined(node ast.Expression, ctx object.Context) (object.Object, error) {
+	switch node := node.(type) {
+	case *ast.IndexExpression:
+		container, err := ev.evalIfDefined(node.Left, ctx)
+		if err != nil || container == object.Null {
+			return container, err
+		}
+		index, err := ev.Eval(node.Index, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		i := container.Class().Methods().Find("__index")
+		if i == nil {
+			return object.Null, nil
+		}
+		if value, err := i.Call(container, index); err == nil {
+			return value, nil
+		}
+		return object.Null, nil
+	case *ast.FetchExpression:
+		name, ok := node.Right.(*ast.Identifier)
+		if !ok {
+			break
+		}
+		obj, err := ev.evalIfDefined(node.Left, ctx)
+		if err != nil || obj == object.Null {
+			return obj, err
+		}
+		if instance, ok := obj.(*object.UserObject); ok {
+			if _, defined := instance.Property(name.Value); defined {
+				return ev.evalPropertyFetch(obj, name, ctx)
+			}
+		}
+		return object.Null, nil
+	}
+	return ev.Eval(node, ctx)
+}
+
+// unaryOperation dispatches prefix operator op to the method of r it's mapped to in unaryMethods,
+// `+` converts r to Int and `!` falls back to negating `__toBoolean` when there is no `__not`
+func unaryOperation(op string, r object.Object) (object.Object, error) {
+	if op == "+" {
+		return object.ToInteger(r)
+	}
+	if m := r.Class().Methods().Find(unaryMethods[op]); m != nil {
+		return m.Call(r)
+	}
+	if op == "!" {
+		b, err := object.ToBoolean(r)
+		if err != nil {
+			return object.Null, err
+		}
+		return object.NativeBool(!b), nil
+	}
+	return nil, object.Throw(object.TypeErrorClass, "operator %s (method %s) is not defined on type %s",
+		op, unaryMethods[op], r.Class().Name())
+}
+
+// assignTarget is the left side of an assignment resolved once, so compound
+// assignments read and write it without evaluating its subexpressions twice
+type assignTarget struct {
+	get func() (object.Object, error)
+	set func(object.Object) error
+}
+
+// resolveTarget evaluates objects, classes and indexes the left side of assignment refers to
+func (ev *evaluator) resolveTarget(node ast.Expression, ctx object.Context) (*assignTarget, error) {
+	switch node := node.(type) {
+	case *ast.VariableExpression:
+		return &assignTarget{
+			get: func() (object.Object, error) {
+				if v, err := ctx.GetContextVar(node.Name); err == nil {
+					return v, nil
+				}
+				return object.Null, nil
+			},
+			set: func(value object.Object) error { return ctx.SetContextVar(node.Name, value) },
+		}, nil
+	case *ast.FetchExpression:
+		obj, err := ev.Eval(node.Left, ctx)
+		if err != nil {
+			return nil, err
+		}
+		name := node.Right.(*ast.Identifier)
+		return &assignTarget{
+			get: func() (object.Object, error) { return ev.evalPropertyFetch(obj, name, ctx) },
+			set: func(value object.Object) error { return setProperty(obj, name.Value, value, ctx) },
+		}, nil
+	case *ast.StaticFetchExpression:
+		class, err := ev.resolveClass(node.Left.(*ast.Identifier), ctx)
+		if err != nil {
+			return nil, err
+		}
+		name := node.Right.(*ast.VariableExpression).Name
+		return &assignTarget{
+			get: func() (object.Object, error) { return staticProperty(class, name, ctx) },
+			set: func(value object.Object) error { return setStaticProperty(class, name, value, ctx) },
+		}, nil
+	case *ast.IndexExpression:
+		return ev.indexTarget(node, ctx)
+	}
+	return nil, fmt.Errorf("can not assign to %s", node.String())
+}
+
+// indexTarget evaluates the container and the index of `$container[$index]`,
+// reading and writing go through its `__index` and `__setIndex` methods
+func (ev *evaluator) indexTarget(node *ast.IndexExpression, ctx object.Context) (*assignTarget, error) {
+	container, err := ev.Eval(node.Left, ctx)
+	if err != nil {
+		return nil, err
+	}
+	index, err := ev.Eval(node.Index, ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &assignTarget{
+		get: func() (object.Object, error) {
+			if i := container.Class().Methods().Find("__index"); i != nil {
+				return i.Call(container, index)
+			}
+			return object.Null, object.Throw(object.TypeErrorClass, "%s does not support indexing", container.Class().Name())
+		},
+		set: func(value object.Object) error {
+			if i := container.Class().Methods().Find("__setIndex"); i != nil {
+				_, err := i.Call(container, index, value)
+				return err
+			}
+			return object.Throw(object.TypeErrorClass, "%s does not support index assignment", container.Class().Name())
+		},
+	}, nil
+}
+
+// evalCompoundAssignment handles `$x += $y` and alike as `$x = $x + $y` with $x evaluated once,
+// `$x ??= $y` evaluates $y only when $x is null
+func (ev *evaluator) evalCompoundAssignment(node *ast.AssignmentExpression, ctx object.Context) (object.Object, error) {
+	target, err := ev.resolveTarget(node.Left, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	current, err := target.get()
+	if node.Operator == "??" {
+		// undefined index or property is just null here, setting it reports whatever is wrong
+		if err == nil && current != object.Null {
+			return current, nil
+		}
+	} else if err != nil {
+		return object.Null, err
+	}
+	right, err := ev.Eval(node.Right, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	if unwinds(right) {
+		return right, nil
+	}
+	value := right
+	if node.Operator != "??" {
+		if value, err = binaryOperation(node.Operator, current, right); err != nil {
+			return object.Null, err
+		}
+	}
+	return value, target.set(value)
+}
+
+// evalIncDec handles `++$x`, `$x++`, `--$x` and `$x--`, prefix ones evaluate to the updated value,
+// postfix ones to the previous one. Incrementing null gives 1, decrementing it leaves it null
+func (ev *evaluator) evalIncDec(node *ast.UnaryExpression, ctx object.Context) (object.Object, error) {
+	target, err := ev.resolveTarget(node.Right, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	current, err := target.get()
+	if err != nil {
+		return object.Null, err
+	}
+	var updated object.Object
+	switch {
+	case current == object.Null && node.Op == "++":
+		updated = &object.IntegerObject{Value: 1}
+	case current == object.Null:
+		updated = object.Null
+	default:
+		if updated, err = binaryOperation(node.Op[:1], current, &object.IntegerObject{Value: 1}); err != nil {
+			return object.Null, err
+		}
+	}
+	if err := target.set(updated); err != nil {
+		return object.Null, err
+	}
+	if node.IsPrefix {
+		return updated, nil
+	}
+	return current, nil
+}
+
+// evalConstructorCall ...
+func (ev *evaluator) evalConstructorCall(node *ast.NewExpression, ctx object.Context) (object.Object, error) {
+	var class object.Object
+	var err error
+	if isRelativeClass(node.ClassName) {
+		class, err = ev.resolveClass(node.ClassName, ctx)
+	} else {
+		class, err = ev.lookup(node.ClassName.Value, ctx)
+	}
+	if err != nil {
+		return object.Null, err
+	}
+	switch class := class.(type) {
+	case *object.Interface:
+		return object.Null, object.Throw(object.ErrorClass, "can not instantiate interface %s", class.Name())
+	case *object.Trait:
+		return object.Null, object.Throw(object.ErrorClass, "can not instantiate trait %s", class.Name())
+	case *object.UserClass:
+		if class.IsAbstract() {
+			return object.Null, object.Throw(object.ErrorClass, "can not instantiate abstract class %s", class.Name())
+		}
+		args, err := ev.evalMethodArgs(node.Args, class.Constructor(), ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		instance := object.NewUserObject(class)
+		if object.IsThrowable(instance) {
+			object.SetTrace(instance, ev.trace())
+		}
+		if constructor := class.Constructor(); constructor != nil {
+			if err := checkMethodAccess(class, "__construct", constructor, ctx); err != nil {
+				return object.Null, err
+			}
+			if _, err := constructor.Call(instance, args...); err != nil {
+				return object.Null, err
+			}
+		}
+		return instance, nil
+	case *object.InternalClass:
+		constructor := class.Constructor()
+		if constructor == nil {
+			return object.Null, object.Throw(object.ErrorClass, "can not instantiate internal class %s", class.Name())
+		}
+		args, err := ev.evalArgs(node.Args, nil, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		return constructor.Call(object.Null, args...)
+	default:
+		return object.Null, object.Throw(object.TypeErrorClass, "%s is not a class but %s", node.ClassName.Value, class.Class().Name())
+	}
+}
+
+// evalTryExpression evaluates try block, an exception thrown in it is handled by the first
+// catch clause matching its class. The value of the expression is the last value of the block
+// evaluated, finally block is always evaluated and only `return` in it overrides the value
+func (ev *evaluator) evalTryExpression(te *ast.TryExpression, ctx object.Context) (object.Object, error) {
+	value, err := ev.Eval(te.Block, ctx)
+	if err != nil {
+		// runtime errors which are not exceptions yet are caught as Error
+		thrown := object.AsThrowError(err)
+		object.SetTrace(thrown.Exception, ev.trace())
+		if catch := ev.findCatch(te.Catches, thrown.Exception, ctx); catch != nil {
+			if catch.Variable != nil {
+				ctx.SetContextVar(catch.Variable.Name, thrown.Exception)
+			}
+			value, err = ev.Eval(catch.Block, ctx)
+		}
+	}
+	if te.Finally != nil {
+		finally, finallyErr := ev.Eval(te.Finally, ctx)
+		if finallyErr != nil {
+			return object.Null, finallyErr
+		}
+		if unwinds(finally) {
+			return finally, nil
+		}
+	}
+
+	return value, err
+}
+
+// evalInstanceOf evaluates `$object instanceof Type`
+func (ev *evaluator) evalInstanceOf(node *ast.InstanceOfExpression, ctx object.Context) (object.Object, error) {
+	o, err := ev.Eval(node.Object, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	var class object.Class
+	if ident, ok := node.Type.(*ast.Identifier); ok {
+		class, err = ev.resolveClass(ident, ctx)
+	} else {
+		class, err = ev.evalClass(node.Type, ctx)
+	}
+	if err != nil {
+		return object.Null, err
+	}
+	return object.NativeBool(object.IsInstanceOf(o.Class(), class)), nil
+}
+
+// evalClass evaluates expression to a class, objects stand for their classes
+func (ev *evaluator) evalClass(expression ast.Expression, ctx object.Context) (object.Class, error) {
+	o, err := ev.Eval(expression, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if class, ok := o.(object.Class); ok {
+		return class, nil
+	}
+	if _, ok := o.(*object.UserObject); ok {
+		return o.Class(), nil
+	}
+	return nil, object.Throw(object.TypeErrorClass, "instanceof expects a class or an object, %s given", o.Class().Name())
+}
+
+// evalMatchExpression evaluates result of the first arm matching the subject,
+// default arm is taken only when no other arm matches
+func (ev *evaluator) evalMatchExpression(me *ast.MatchExpression, ctx object.Context) (object.Object, error) {
+	subject, err := ev.Eval(me.Subject, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	var fallback *ast.MatchArm
+	for _, arm := range me.Arms {
+		if arm.IsDefault() {
+			fallback = arm
+			continue
+		}
+		matched, err := ev.matchArm(arm, subject, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		if matched {
+			return ev.Eval(arm.Result, ctx)
+		}
+	}
+	if fallback != nil {
+		return ev.Eval(fallback.Result, ctx)
+	}
+	return object.Null, object.Throw(object.UnhandledMatchErrorClass, "unhandled match case %s", describeValue(subject))
+}
+
+// matchArm reports whether subject matches one of conditions of arm and its guard holds
+func (ev *evaluator) matchArm(arm *ast.MatchArm, subject object.Object, ctx object.Context) (bool, error) {
+	for _, condition := range arm.Conditions {
+		matched, err := ev.matchCondition(condition, subject, ctx)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			continue
+		}
+		if arm.Guard == nil {
+			return true, nil
+		}
+		return ev.evalCondition(arm.Guard, ctx)
+	}
+	return false, nil
+}
+
+// matchCondition reports whether subject matches condition, names of classes
+// test the type of subject as instanceof does, other values must be identical to it
+func (ev *evaluator) matchCondition(condition ast.Expression, subject object.Object, ctx object.Context) (bool, error) {
+	if ident, ok := condition.(*ast.Identifier); ok {
+		if class, err := ev.resolveClass(ident, ctx); err == nil {
+			// class values match their own names
+			return subject == class || object.IsInstanceOf(subject.Class(), class), nil
+		}
+	}
+	value, err := ev.Eval(condition, ctx)
+	if err != nil {
+		return false, err
+	}
+	return identical(subject, value)
+}
+
+// identical reports whether l and r are the same object or equal values of the same class
+func identical(l, r object.Object) (bool, error) {
+	if l == r {
+		return true, nil
+	}
+	if l.Class() != r.Class() {
+		return false, nil
+	}
+	// the loose `__compare` would treat "1" and "01" as equal
+	switch l := l.(type) {
+	case *object.IntegerObject:
+		return l.Value == r.(*object.IntegerObject).Value, nil
+	case *object.StringObject:
+		return l.Value == r.(*object.StringObject).Value, nil
+	case *object.BooleanObject:
+		return l.Value == r.(*object.BooleanObject).Value, nil
+	case *object.ArrayObject:
+		ra := r.(*object.ArrayObject)
+		if len(l.Values) != len(ra.Values) {
+			return false, nil
+		}
+		for i := range l.Values {
+			if same, err := identical(l.Values[i], ra.Values[i]); !same || err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	// objects are identical only to themselves
+	return false, nil
+}
+
+// describeValue returns strings and integers as they are and names the class of other objects
+func describeValue(o object.Object) string {
+	switch o := o.(type) {
+	case *object.StringObject:
+		return strconv.Quote(o.Value)
+	case *object.IntegerObject:
+		return strconv.FormatInt(o.Value, 10)
+	}
+	return "of type " + o.Class().Name()
+}
+
+// evalSwitchExpression evaluates bodies of cases starting from the first one
+// loosely equal to the subject, or from default, until break, switch evaluates to null
+func (ev *evaluator) evalSwitchExpression(se *ast.SwitchExpression, ctx object.Context) (object.Object, error) {
+	subject, err := ev.Eval(se.Subject, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	start := -1
+	for i, cc := range se.Cases {
+		if cc.IsDefault() {
+			continue
+		}
+		value, err := ev.Eval(cc.Value, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		equal, err := looselyEqual(subject, value)
+		if err != nil {
+			return object.Null, err
+		}
+		if equal {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		for i, cc := range se.Cases {
+			if cc.IsDefault() {
+				start = i
+			}
+		}
+	}
+	if start == -1 {
+		return object.Null, nil
+	}
+	// cases fall through until break, switch counts as a loop for break and continue
+	for _, cc := range se.Cases[start:] {
+		r, err := ev.Eval(cc.Body, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		switch r := r.(type) {
+		case returnObject:
+			return r, nil
+		case loopControl:
+			if r.levels > 1 {
+				return loopControl{isContinue: r.isContinue, levels: r.levels - 1}, nil
+			}
+			return object.Null, nil
+		}
+	}
+	return object.Null, nil
+}
+
+// looselyEqual compares l and r with `__equal` of l falling back to its `__compare`,
+// objects with neither are equal only to themselves
+func looselyEqual(l, r object.Object) (bool, error) {
+	equal := l.Class().Methods().Find(opMethods["=="])
+	if equal == nil {
+		if l.Class().Methods().Find("__compare") == nil {
+			return l == r, nil
+		}
+		c, err := object.Compare(l, r)
+		return c == 0, err
+	}
+	result, err := equal.Call(l, r)
+	if err != nil {
+		return false, err
+	}
+	return result == object.True, nil
+}
+
+// findCatch returns the first catch clause which type exception is an instance of,
+// clauses with classes which are not declared never match
+func (ev *evaluator) findCatch(catches []*ast.CatchClause, exception object.Object, ctx object.Context) *ast.CatchClause {
+	for _, catch := range catches {
+		o, err := ev.lookup(catch.Type.Value, ctx)
+		if err != nil {
+			continue
+		}
+		if class, ok := o.(object.Class); ok && object.IsInstanceOf(exception.Class(), class) {
+			return catch
+		}
+	}
+	return nil
+}
+
+// evalIndexExpression ...
+func (ev *evaluator) evalIndexExpression(node *ast.IndexExpression, ctx object.Context) (object.Object, error) {
+	l, err := ev.Eval(node.Left, ctx)
+	if err != nil {
+		return nil, err
+	}
+	index, err := ev.Eval(node.Index, ctx)
+	if err != nil {
+		return nil, err
+	}
+	if i := l.Class().Methods().Find("__index"); i != nil {
+		return i.Call(l, index)
+	}
+	return object.Null, object.Throw(object.TypeErrorClass, "%s does not support indexing", l.Class().Name())
+}
+
+// registerUserClass builds a class from its declaration and puts it into globals table
+func (ev *evaluator) registerUserClass(cde *ast.ClassDeclarationExpression, ctx object.Context) (object.Object, error) {
+	name := object.FullyQ(ev.state.namespace, cde.Name.Value)
+
+	var superClass object.Class
+	if cde.Parent != nil {
+		parent, err := ev.lookup(cde.Parent.Value, ctx)
+		if err != nil {
+			return object.Null, err
+		}
+		parentClass, ok := parent.(*object.UserClass)
+		if !ok {
+			return object.Null, object.Throw(object.TypeErrorClass, "class %s can not extend %s", name, cde.Parent.Value)
+		}
+		if parentClass.IsFinal() {
+			return object.Null, object.Throw(object.ErrorClass, "class %s can not extend final class %s", name, parentClass.Name())
+		}
+		superClass = parentClass
+	}
+	interfaces, err := ev.lookupInterfaces(cde.Interfaces, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+	if superClass == nil || !object.IsInstanceOf(superClass, object.ThrowableInterface) {
+		for _, iface := range interfaces {
+			if object.IsInstanceOf(iface, object.ThrowableInterface) {
+				return object.Null, object.Throw(object.ErrorClass, "class %s can not implement Throwable directly, extend Exception or Error instead", name)
+			}
+		}
+	}
+
+	methods := make(map[string]object.Method)
+	properties := make(map[string]object.Object)
+	class := object.NewUserClass(name, superClass, interfaces, cde.IsFinal, cde.IsAbstract, methods, properties)
+	// constants and defaults may refer to the class itself through `self::` and `static::`
+	classCtx := object.CloneContext(ctx, ctx.Scope())
+	classCtx.SetScopeClass(class)
+	classCtx.SetCalledClass(class)
+
+	// methods declared in the class itself override the ones of traits
+	declared := make(map[string]bool)
+	for _, st := range cde.Block.Statements {
+		es, ok := st.(*ast.ExpressionStatement)
+		if !ok {
+			return object.Null, object.Throw(object.ErrorClass, "unexpected %s in class %s", st.String(), name)
+		}
+		if mde, ok := es.Expression.(*ast.MethodDeclarationExpression); ok {
+			if declared[mde.Name.Value] {
+				return object.Null, object.Throw(object.ErrorClass, "can not redeclare method %s::%s", name, mde.Name.Value)
+			}
+			declared[mde.Name.Value] = true
+		}
+	}
+	for _, st := range cde.Block.Statements {
+		if use, ok := st.(*ast.ExpressionStatement).Expression.(*ast.TraitUseExpression); ok {
+			if err := ev.useTraits(class, use, declared, methods, properties, classCtx); err != nil {
+				return object.Null, err
+			}
+		}
+	}
+
+	for _, st := range cde.Block.Statements {
+		es := st.(*ast.ExpressionStatement)
+		switch member := es.Expression.(type) {
+		case *ast.TraitUseExpression:
+			// already applied
+		case *ast.MethodDeclarationExpression:
+			ev.declareMethod(class, methods, member, ctx)
+		case *ast.PropertyDeclarationExpression:
+			if err := ev.declareProperty(class, properties, member, classCtx); err != nil {
+				return object.Null, err
+			}
+		case *ast.AssignmentExpression:
+			constant, ok := member.Left.(*ast.ConstantExpression)
+			if !ok {
+				return object.Null, object.Throw(object.ErrorClass, "unexpected %s in class %s", es.Expression.String(), name)
+			}
+			value, err := ev.Eval(member.Right, classCtx)
+			if err != nil {
+				return object.Null, err
+			}
+			if err := class.DeclareConstant(constant.Name.Value, value); err != nil {
+				return object.Null, err
+			}
+		default:
+			return object.Null, object.Throw(object.ErrorClass, "unexpected %s in class %s", es.Expression.String(), name)
+		}
+	}
+	if err := checkContracts(class); err != nil {
+		return object.Null, err
+	}
+
+	return object.Null, declareGlobal(ctx, "class", name, class)
+}
+
+// useTraits copies methods and properties of traits into class. A method provided by
+// several traits must be either declared in the class or resolved with `insteadof`
+func (ev *evaluator) useTraits(class *object.UserClass, use *ast.TraitUseExpression, declared map[string]bool,
+	methods map[string]object.Method, properties map[string]object.Object, ctx object.Context) error {
+	traits := make(map[string]*object.Trait, len(use.Traits))
+	for _, name := range use.Traits {
+		o, err := ev.lookup(name.Value, ctx)
+		if err != nil {
+			return err
+		}
+		trait, ok := o.(*object.Trait)
+		if !ok {
+			return object.Throw(object.TypeErrorClass, "%s is not a trait", name.Value)
+		}
+		traits[name.Value] = trait
+	}
+
+	// `First::method insteadof Second` excludes Second::method
+	excluded := make(map[string]bool)
+	for _, rule := range use.Rules {
+		if rule.Trait == nil {
+			continue
+		}
+		trait, ok := traits[rule.Trait.Value]
+		if !ok {
+			return object.Throw(object.ErrorClass, "trait %s is not used in class %s", rule.Trait.Value, class.Name())
+		}
+		if trait.Method(rule.Method.Value) == nil {
+			return object.Throw(object.UndefinedNameErrorClass, "trait %s has no method %s", rule.Trait.Value, rule.Method.Value)
+		}
+		for _, other := range rule.InsteadOf {
+			if _, ok := traits[other.Value]; !ok {
+				return object.Throw(object.ErrorClass, "trait %s is not used in class %s", other.Value, class.Name())
+			}
+			excluded[other.Value+"::"+rule.Method.Value] = true
+		}
+	}
+
+	providers := make(map[string][]string)
+	for _, name := range use.Traits {
+		for _, method := range traits[name.Value].MethodNames() {
+			if !excluded[name.Value+"::"+method] {
+				providers[method] = append(providers[method], name.Value)
+			}
+		}
+	}
+	for method, names := range providers {
+		if declared[method] {
+			continue
+		}
+		if len(names) > 1 {
+			return object.Throw(object.ErrorClass, "method %s is provided by traits %s in class %s, resolve the conflict with insteadof",
+				method, strings.Join(names, " and "), class.Name())
+		}
+		mde := traits[names[0]].Method(method)
+		ev.declareMethod(class, methods, mde, ctx)
+	}
+
+	// `Trait::method as protected alias` adds the method once again under a new name,
+	// `method as protected` changes visibility of the copied one
+	for _, rule := range use.Rules {
+		if len(rule.InsteadOf) != 0 {
+			continue
+		}
+		var traitName string
+		if rule.Trait != nil {
+			traitName = rule.Trait.Value
+		} else if names := providers[rule.Method.Value]; len(names) == 1 {
+			traitName = names[0]
+		} else {
+			return object.Throw(object.ErrorClass, "can not resolve trait of method %s in class %s", rule.Method.Value, class.Name())
+		}
+		alias := *traits[traitName].Method(rule.Method.Value)
+		if rule.HasAccess {
+			alias.Access = rule.Access
+		}
+		if rule.Alias != nil {
+			alias.Name = rule.Alias
+		}
+		if !declared[alias.Name.Value] {
+			ev.declareMethod(class, methods, &alias, ctx)
+		}
+	}
+
+	for _, trait := range traits {
+		for _, pde := range trait.Properties() {
+			if err := ev.declareProperty(class, properties, pde, ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// registerTrait puts a trait into globals table, its methods are built for every class using it
+func (ev *evaluator) registerTrait(tde *ast.TraitDeclarationExpression, ctx object.Context) (object.Object, error) {
+	name := object.FullyQ(ev.state.namespace, tde.Name.Value)
+	methods := make(map[string]*ast.MethodDeclarationExpression)
+	properties := make([]*ast.PropertyDeclarationExpression, 0, 4)
+
+	for _, st := range tde.Block.Statements {
+		switch member := st.(*ast.ExpressionStatement).Expression.(type) {
+		case *ast.MethodDeclarationExpression:
+			if _, ok := methods[member.Name.Value]; ok {
+				return object.Null, object.Throw(object.ErrorClass, "can not redeclare method %s::%s", name, member.Name.Value)
+			}
+			methods[member.Name.Value] = member
+		case *ast.PropertyDeclarationExpression:
+			properties = append(properties, member)
+		}
+	}
+
+	return object.Null, declareGlobal(ctx, "trait", name, object.NewTrait(name, methods, properties))
+}
+
+// registerInterface builds an interface from its declaration and puts it into globals table
+func (ev *evaluator) registerInterface(ide *ast.InterfaceDeclarationExpression, ctx object.Context) (object.Object, error) {
+	name := object.FullyQ(ev.state.namespace, ide.Name.Value)
+	parents, err := ev.lookupInterfaces(ide.Parents, ctx)
+	if err != nil {
+		return object.Null, err
+	}
+
+	methods := make(map[string]object.Method)
+	iface := object.NewInterface(name, parents, methods)
+	for _, st := range ide.Block.Statements {
+		mde := st.(*ast.ExpressionStatement).Expression.(*ast.MethodDeclarationExpression)
+		methods[mde.Name.Value] = object.NewAbstractMethod(iface, mde.Name.Value,
+			visibility(mde.Access), object.NewUserFunc(mde.Args, mde.ReturnType, nil))
+	}
+
+	return object.Null, declareGlobal(ctx, "interface", name, iface)
+}
+
+// lookupInterfaces resolves names listed in `implements` or interface `extends`
+func (ev *evaluator) lookupInterfaces(names []*ast.Identifier, ctx object.Context) ([]object.Class, error) {
+	interfaces := make([]object.Class, len(names))
+	for i, name := range names {
+		o, err := ev.lookup(name.Value, ctx)
+		if err != nil {
+			return nil, err
+		}
+		iface, ok := o.(*object.Interface)
+		if !ok {
+			return nil, object.Throw(object.TypeErrorClass, "%s is not an interface", name.Value)
+		}
+		interfaces[i] = iface
+	}
+	return interfaces, nil
+}
+
+// checkContracts verifies that class implements methods of its interfaces and
+// abstract methods of its parents with compatible signatures. Abstract classes
+// may leave them unimplemented
+func checkContracts(class *object.UserClass) error {
+	for _, iface := range allInterfaces(class) {
+		for _, m := range interfaceMethods(iface) {
+			declared := m.(*object.UserMethod)
+			impl, ok := class.Methods().Find(declared.Name()).(*object.UserMethod)
+			if !ok || impl.IsAbstract() {
+				if class.IsAbstract() {
+					continue
+				}
+				return object.Throw(object.ErrorClass, "class %s must implement method %s::%s", class.Name(), iface.Name(), declared.Name())
+			}
+			if impl.Visibility() != object.VisibilityPublic {
+				return object.Throw(object.ErrorClass, "%s::%s must be public as declared in interface %s",
+					impl.DeclaringClass().Name(), impl.Name(), iface.Name())
+			}
+			if !compatible(declared, impl) {
+				return incompatibleError(declared, impl)
+			}
+		}
+	}
+
+	for _, m := range class.Methods().All() {
+		impl, ok := m.(*object.UserMethod)
+		if !ok {
+			continue
+		}
+		if impl.IsAbstract() {
+			if !class.IsAbstract() {
+				return object.Throw(object.ErrorClass, "class %s must implement abstract method %s::%s",
+					class.Name(), impl.DeclaringClass().Name(), impl.Name())
+			}
+			continue
+		}
+		if class.SuperClass() == nil {
+			continue
+		}
+		declared, ok := class.SuperClass().Methods().Find(impl.Name()).(*object.UserMethod)
+		if ok && declared.IsAbstract() && !compatible(declared, impl) {
+			return incompatibleError(declared, impl)
+		}
+	}
+
+	return nil
+}
+
+// allInterfaces returns interfaces implemented by class and all its parents
+func allInterfaces(class object.Class) []object.Class {
+	interfaces := make([]object.Class, 0, 4)
+	for c := class; c != nil; c = c.SuperClass() {
+		interfaces = append(interfaces, c.Interfaces()...)
+	}
+	return interfaces
+}
+
+// interfaceMethods returns methods declared by iface and interfaces it extends
+func interfaceMethods(iface object.Class) []object.Method {
+	methods := iface.Methods().All()
+	for _, parent := range iface.Interfaces() {
+		methods = append(methods, interfaceMethods(parent)...)
+	}
+	return methods
+}
+
+// compatible reports whether implementation accepts every call valid for declaration:
+// it has at least the same arguments of the same or omitted types, extra ones being optional,
+// and returns the declared type if there is one
+func compatible(declaration, implementation *object.UserMethod) bool {
+	declared, implemented := declaration.Function().Args(), implementation.Function().Args()
+	if len(implemented) < len(declared) {
+		return false
+	}
+	if returnType := declaration.Function().ReturnType(); returnType != nil {
+		implType := implementation.Function().ReturnType()
+		if implType == nil || !strings.EqualFold(implType.Value, returnType.Value) {
+			return false
+		}
+	}
+	for i, arg := range implemented {
+		if i >= len(declared) {
+			if arg.DefaultValue == nil && !arg.Variadic {
+				return false
+			}
+			continue
+		}
+		if arg.Type == nil {
+			continue
+		}
+		if declared[i].Type == nil || !strings.EqualFold(arg.Type.Value, declared[i].Type.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+func incompatibleError(declaration, implementation *object.UserMethod) error {
+	return object.Throw(object.ErrorClass, "declaration of %s::%s%s must be compatible with %s::%s%s",
+		implementation.DeclaringClass().Name(), implementation.Name(), signature(implementation),
+		declaration.DeclaringClass().Name(), declaration.Name(), signature(declaration))
+}
+
+// signature returns parenthesized arguments of the method followed by its return type
+func signature(m *object.UserMethod) string {
+	args := make([]string, len(m.Function().Args()))
+	for i, arg := range m.Function().Args() {
+		args[i] = arg.String()
+	}
+	out := "(" + strings.Join(args, ", ") + ")"
+	if returnType := m.Function().ReturnType(); returnType != nil {
+		out += ": " + returnType.Value
+	}
+	return out
+}
+
+// declareMethod makes either an abstract or a regular method and adds it to methods,
+// static methods go to the class itself
+func (ev *evaluator) declareMethod(class *object.UserClass, methods map[string]object.Method, mde *ast.MethodDeclarationExpression, ctx object.Context) {
+	var method object.Method
+	if mde.IsAbstract {
+		method = object.NewAbstractMethod(class, mde.Name.Value, visibility(mde.Access), object.NewUserFunc(mde.Args, mde.ReturnType, nil))
+	} else {
+		method = ev.newUserMethod(class, mde, ctx)
+	}
+	if mde.IsStatic {
+		class.DeclareStaticMethod(mde.Name.Value, method)
+		return
+	}
+	methods[mde.Name.Value] = method
+}
+
+// declareProperty evaluates default value of a property and adds it to properties,
+// static properties go to the class itself
+func (ev *evaluator) declareProperty(class *object.UserClass, properties map[string]object.Object, pde *ast.PropertyDeclarationExpression, ctx object.Context) error {
+	value, err := ev.evalPropertyDefault(pde, ctx)
+	if err != nil {
+		return err
+	}
+	class.DeclarePropertyVisibility(pde.Name.Name, visibility(pde.Access))
+	if pde.IsStatic {
+		class.DeclareStaticProperty(pde.Name.Name, value)
+		return nil
+	}
+	properties[pde.Name.Name] = value
+	return nil
+}
+
+// evalPropertyDefault evaluates default value of a property, it's null if omitted
+func (ev *evaluator) evalPropertyDefault(pde *ast.PropertyDeclarationExpression, ctx object.Context) (object.Object, error) {
+	if pde.DefaultValue == nil {
+		return object.Null, nil
+	}
+	return ev.Eval(pde.DefaultValue, ctx)
+}
+
+// newUserMethod makes a method which evaluates its body with `$this` bound to the callee
+// and class as the scope for `self::` and `parent::`. Static methods are called
+// with the class they're called on instead, it's the one `static::` refers to
+func (ev *evaluator) newUserMethod(class object.Class, mde *ast.MethodDeclarationExpression, ctx object.Context) object.Method {
+	fun := object.NewUserFunc(mde.Args, mde.ReturnType, mde.Block)
+	frame := class.Name() + "->" + mde.Name.Value
+	if mde.IsStatic {
+		frame = class.Name() + "::" + mde.Name.Value
+	}
+	invoke := func(this object.Object, args ...object.Object) (object.Object, error) {
+		funCtx := ev.newCallContext(ctx, fun)
+		if mde.IsStatic {
+			funCtx.SetCalledClass(this.(object.Class))
+		} else {
+			funCtx.SetContextVar("this", this)
+			funCtx.SetCalledClass(this.Class())
+		}
+		funCtx.SetScopeClass(class)
+
+		return ev.call(frame, func() (object.Object, error) {
+			return ev.invoke(funCtx, frame, fun, args)
+		})
+	}
+
+	if mde.IsStatic {
+		return object.NewStaticMethod(class, mde.Name.Value, visibility(mde.Access), fun, invoke)
+	}
+	return object.NewUserMethod(class, mde.Name.Value, visibility(mde.Access), fun, invoke)
+}
+
+// visibility converts access modifier of a declaration to object.Visibility
+func visibility(access int32) object.Visibility {
+	switch access {
+	case ast.ModPrivate:
+		return object.VisibilityPrivate
+	case ast.ModProtected:
+		return object.VisibilityProtected
+	default:
+		return object.VisibilityPublic
+	}
 }
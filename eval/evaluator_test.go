@@ -3,8 +3,6 @@ package eval
 import (
 	"github.com/pmukhin/gophp/ast"
 	"github.com/pmukhin/gophp/object"
-	"github.com/pmukhin/gophp/parser"
-	"github.com/pmukhin/gophp/scanner"
 	"testing"
 )
 
@@ -13,7 +11,7 @@ func checkContextVariableInt(t *testing.T, ctx object.Context, name string, valu
 		t.Error(err)
 	} else {
 		if e, ok := el.(*object.IntegerObject); !ok {
-			t.Errorf("%v is not Integer, it's %s", e.Class().Name())
+			t.Errorf("$%s is not Integer, it's %s", name, el.Class().Name())
 		} else {
 			if e.Value != value {
 				t.Errorf("$%s is %d, not %d", name, e.Value, value)
@@ -87,7 +85,8 @@ func TestEval(t *testing.T) {
 			},
 		}
 		ctx := object.NewContext(nil)
-		_, e := Eval(p, ctx)
+		object.RegisterGlobals(ctx)
+		_, e := New().Eval(p, ctx)
 		if e != nil {
 			t.Error(e)
 		}
@@ -156,7 +155,8 @@ func TestEval(t *testing.T) {
 			},
 		}
 		ctx := object.NewContext(nil)
-		_, e := Eval(p, ctx)
+		object.RegisterGlobals(ctx)
+		_, e := New().Eval(p, ctx)
 		if e != nil {
 			t.Error(e)
 		}
@@ -170,64 +170,40 @@ func TestEval(t *testing.T) {
 	})
 
 	t.Run("print string var", func(t *testing.T) {
-		p := parser.New(scanner.New([]rune(`
+		_, e := evalCode(`
 			println(5)
-		`)))
-		program, e := p.Parse()
-		if e != nil {
-			t.Error(e)
-		}
-		ctx := object.NewContext(nil)
-		_, e = Eval(program, ctx)
+		`)
 		if e != nil {
 			t.Error(e)
 		}
 	})
 
 	t.Run("print string var", func(t *testing.T) {
-		p := parser.New(scanner.New([]rune(`
+		_, e := evalCode(`
 			$str = 'hello'
 			println($str)
-		`)))
-		program, e := p.Parse()
-		if e != nil {
-			t.Error(e)
-		}
-		ctx := object.NewContext(nil)
-		_, e = Eval(program, ctx)
+		`)
 		if e != nil {
 			t.Error(e)
 		}
 	})
 
 	t.Run("evaluate user function", func(t *testing.T) {
-		p := parser.New(scanner.New([]rune(`
+		_, e := evalCode(`
 			function test() {}
 			$var = test();
 			println($var)
-		`)))
-		program, e := p.Parse()
-		if e != nil {
-			t.Error(e)
-		}
-		ctx := object.NewContext(nil)
-		_, e = Eval(program, ctx)
+		`)
 		if e != nil {
 			t.Error(e)
 		}
 	})
 
 	t.Run("evaluate bool expression and print", func(t *testing.T) {
-		p := parser.New(scanner.New([]rune(`
+		_, e := evalCode(`
 			$is = 5 > 5
 			println($is)
-`)))
-		program, e := p.Parse()
-		if e != nil {
-			t.Error(e)
-		}
-		ctx := object.NewContext(nil)
-		_, e = Eval(program, ctx)
+`)
 		if e != nil {
 			t.Error(e)
 		}
@@ -260,7 +236,8 @@ func TestEval(t *testing.T) {
 			},
 		}
 		ctx := object.NewContext(nil)
-		_, e := Eval(p, ctx)
+		object.RegisterGlobals(ctx)
+		_, e := New().Eval(p, ctx)
 		if e != nil {
 			t.Error(e)
 		}
@@ -285,7 +262,8 @@ func TestEval(t *testing.T) {
 			},
 		}
 		ctx := object.NewContext(nil)
-		_, e := Eval(p, ctx)
+		object.RegisterGlobals(ctx)
+		_, e := New().Eval(p, ctx)
 		if e != nil {
 			t.Error(e)
 		}
@@ -300,7 +278,7 @@ func TestEval(t *testing.T) {
 }
 
 func TestEval_Fibonacci_OldSyntax(t *testing.T) {
-	p := parser.New(scanner.New([]rune(`
+	ctx, e := evalCode(`
 			function fib($n) { 
 				if $n < 2 { 
 					return 1; 
@@ -311,13 +289,7 @@ func TestEval_Fibonacci_OldSyntax(t *testing.T) {
 			$result1 = fib(1);
 			$result2 = fib(2);
 			$result3 = fib(3);
-	`)))
-	program, e := p.Parse()
-	if e != nil {
-		t.Error(e)
-	}
-	ctx := object.NewContext(nil)
-	_, e = Eval(program, ctx)
+	`)
 	if e != nil {
 		t.Error(e)
 	}
@@ -327,19 +299,13 @@ func TestEval_Fibonacci_OldSyntax(t *testing.T) {
 }
 
 func TestEval_Fibonacci_NewSyntax(t *testing.T) {
-	p := parser.New(scanner.New([]rune(`
+	ctx, e := evalCode(`
 			function fib($n) { if $n < 2 { $n } else { fib($n - 2) + fib($n - 1) } }
 			$result1 = fib(0)
 			$result2 = fib(1)
 			$result3 = fib(2)
 			$result4 = fib(3)
-	`)))
-	program, e := p.Parse()
-	if e != nil {
-		t.Error(e)
-	}
-	ctx := object.NewContext(nil)
-	_, e = Eval(program, ctx)
+	`)
 	if e != nil {
 		t.Error(e)
 	}
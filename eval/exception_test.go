@@ -0,0 +1,180 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+)
+
+const exceptionClasses = `
+	interface Problem {}
+	class MyException extends Exception implements Problem {}
+	class NotFound extends MyException {}
+`
+
+func TestEval_Try(t *testing.T) {
+	ctx, err := evalCode(exceptionClasses + `
+		function find($name) {
+			throw new NotFound($name + " not found")
+		}
+		function safe($name) {
+			try {
+				find($name)
+			} catch (NotFound $e) {
+				return "caught " + $e->getMessage()
+			} finally {
+				"ignored"
+			}
+		}
+
+		$value = try { find("a") } catch (MyException $e) { 0 }
+		$message = $e->getMessage()
+		$byInterface = try { find("b") } catch (Problem) { "problem" }
+		$first = try { find("c") } catch (NotFound $e) { 1 } catch (MyException $e) { 2 }
+		$safe = safe("d")
+		$plain = try { 5 } catch (NotFound $e) { 0 }
+		$ran = 0
+		$result = try { 6 } finally { $ran = 7 }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "value", "0")
+	checkContextVariable(t, ctx, "message", "'a not found'")
+	checkContextVariable(t, ctx, "byInterface", "'problem'")
+	checkContextVariable(t, ctx, "first", "1")
+	checkContextVariable(t, ctx, "safe", "'caught d not found'")
+	checkContextVariable(t, ctx, "plain", "5")
+	checkContextVariable(t, ctx, "result", "6")
+	checkContextVariable(t, ctx, "ran", "7")
+}
+
+func TestEval_Try_Finally(t *testing.T) {
+	ctx, err := evalCode(exceptionClasses + `
+		$log = ""
+		function run() {
+			try {
+				throw new NotFound("x")
+			} finally {
+				return "finally"
+			}
+		}
+		$overridden = run()
+		try {
+			try {
+				throw new NotFound("inner")
+			} catch (Problem $e) {
+				throw new MyException("rethrown")
+			} finally {
+				$log = $log + "finally "
+			}
+		} catch (MyException $e) {
+			$log = $log + $e->getMessage()
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "overridden", "'finally'")
+	checkContextVariable(t, ctx, "log", "'finally rethrown'")
+}
+
+func TestEval_Throw_Uncaught(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`throw new NotFound("missing")`, "uncaught NotFound: missing"},
+		{`try { throw new MyException("other") } catch (NotFound $e) { 0 }`, "uncaught MyException: other"},
+		{`try { throw new NotFound("x") } catch (Undeclared $e) { 0 }`, "uncaught NotFound: x"},
+		{`throw 1
+		`, "can only throw objects"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(exceptionClasses + tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestEval_Exceptions_Builtin(t *testing.T) {
+	ctx, err := evalCode(`
+		function div($a, $b) { $a / $b }
+
+		$division = try { div(1, 0) } catch (DivisionByZeroError $e) { $e->getMessage() }
+		$arithmetic = try { 1 % 0 } catch (ArithmeticError $e) { "arithmetic" }
+		$undefined = try { undefined() } catch (UndefinedNameError $e) { "undefined" }
+		$type = try { [] + 1 } catch (TypeError $e) { "type" }
+		$count = try { [1]->append() } catch (TypeError $e) { $e->__toString() }
+		$index = try { "abc"[5] } catch (IndexError $e) { "index" }
+		$runtime = try { "a"->foo() } catch (Error $e) { $e->getMessage() }
+		$os = try { throw new os\Exception("io") } catch (Exception $e) { $e->__toString() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "division", "'division by zero is forbidden'")
+	checkContextVariable(t, ctx, "arithmetic", "'arithmetic'")
+	checkContextVariable(t, ctx, "undefined", "'undefined'")
+	checkContextVariable(t, ctx, "type", "'type'")
+	checkContextVariable(t, ctx, "count", "'ArgumentCountError: at least 1 argument expected'")
+	checkContextVariable(t, ctx, "index", "'index'")
+	checkContextVariable(t, ctx, "runtime", "'method foo is not found in class String'")
+	checkContextVariable(t, ctx, "os", "'os\\Exception: io'")
+}
+
+func TestEval_Exceptions_UserDefined(t *testing.T) {
+	ctx, err := evalCode(`
+		class AppException extends Exception {
+			public function __construct($message, $previous) {
+				parent::__construct("app: " + $message, 42, $previous)
+			}
+		}
+		function load() { throw new AppException("boom", new Exception("cause")) }
+
+		$e = try { load() } catch (Throwable $e) { $e }
+		$message = $e->getMessage()
+		$code = $e->getCode()
+		$previous = $e->getPrevious()->getMessage()
+		$trace = $e->getTrace()->__toString()
+		$hidden = try { $e->message } catch (Error $error) { $error->getMessage() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "message", "'app: boom'")
+	checkContextVariable(t, ctx, "code", "42")
+	checkContextVariable(t, ctx, "previous", "'cause'")
+	checkContextVariable(t, ctx, "trace", "'[load]'")
+	checkContextVariable(t, ctx, "hidden", "'can not access protected property Exception::$message from global scope'")
+}
+
+func TestEval_Exceptions_NamedArguments(t *testing.T) {
+	ctx, err := evalCode(`
+		class AppException extends Exception {}
+
+		$message = (new Exception(message: "x"))->getMessage()
+		$e = new TypeError(code: 3, previous: new Exception("cause"))
+		$code = $e->getCode()
+		$empty = $e->getMessage()
+		$previous = $e->getPrevious()->getMessage()
+		$inherited = (new AppException("y", code: 7))->getCode()
+		$unknown = try { new Exception(text: "x") } catch (Error $e) { $e->getMessage() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "message", "'x'")
+	checkContextVariable(t, ctx, "code", "3")
+	checkContextVariable(t, ctx, "empty", "''")
+	checkContextVariable(t, ctx, "previous", "'cause'")
+	checkContextVariable(t, ctx, "inherited", "7")
+	checkContextVariable(t, ctx, "unknown", "'unknown named argument $text'")
+}
+
+func TestEval_Exceptions_ImplementThrowable(t *testing.T) {
+	_, err := evalCode(`class Custom implements Throwable {}`)
+	if err == nil || !strings.Contains(err.Error(), "can not implement Throwable directly") {
+		t.Errorf("expected Throwable error, got %v", err)
+	}
+}
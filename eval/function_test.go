@@ -0,0 +1,220 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval_Closure(t *testing.T) {
+	ctx, err := evalCode(`
+		$factor = 3
+		$count = 0
+		$mul = function($x) use ($factor, &$count) {
+			$count = $count + 1
+			$factor = $factor + 100
+			return $x * $factor
+		}
+		$first = $mul(2)
+		$second = $mul(2)
+		$factor = 5
+		$third = $mul(1)
+
+		$late = function() use (&$fresh) { $fresh = "set" }
+		$late()
+
+		$secret = "outer"
+		function isolated() { return $secret }
+		$isolated = isolated()
+		$noUse = function() { return $secret }
+		$closureIsolated = $noUse()
+
+		class Counter {
+			private $n = 10
+
+			public function adder() {
+				return function($d) { $this->n = $this->n + $d; return $this->n }
+			}
+		}
+		$add = (new Counter())->adder()
+		$add(5)
+		$counted = $add(5)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "first", "206")
+	checkContextVariable(t, ctx, "second", "206")
+	checkContextVariable(t, ctx, "count", "3")
+	checkContextVariable(t, ctx, "factor", "5")
+	checkContextVariable(t, ctx, "third", "103")
+	checkContextVariable(t, ctx, "fresh", "'set'")
+	checkContextVariable(t, ctx, "isolated", "null")
+	checkContextVariable(t, ctx, "closureIsolated", "null")
+	checkContextVariable(t, ctx, "counted", "20")
+}
+
+func TestEval_DefaultArgs(t *testing.T) {
+	ctx, err := evalCode(`
+		function greet($name, $greeting = "Hello", $suffix = $greeting + "!") {
+			return $greeting + ", " + $name + " " + $suffix
+		}
+		$defaults = greet("Bob")
+		$dependent = greet("Bob", "Hi")
+		$given = greet("Bob", "Hi", "?")
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "defaults", "'Hello, Bob Hello!'")
+	checkContextVariable(t, ctx, "dependent", "'Hi, Bob Hi!'")
+	checkContextVariable(t, ctx, "given", "'Hi, Bob ?'")
+}
+
+func TestEval_ArgumentCount(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`function two($a, $b) { $a }
+		two(1)`, "too few arguments to two($a, $b), 1 given, exactly 2 expected"},
+		{`function two($a, $b) { $a }
+		two(1, 2, 3)`, "too many arguments to two($a, $b), 3 given, exactly 2 expected"},
+		{`function opt($a, $b = 1) { $a }
+		opt()`, "too few arguments to opt($a, $b = 1), 0 given, at least 1 expected"},
+		{`function opt($a, $b = 1) { $a }
+		opt(1, 2, 3)`, "too many arguments to opt($a, $b = 1), 3 given, at most 2 expected"},
+		{`$f = function($x) { $x }
+		$f()`, "too few arguments to {closure}($x), 0 given, exactly 1 expected"},
+		{`class A { public function m($x) { $x } }
+		(new A())->m()`, "too few arguments to A->m($x), 0 given, exactly 1 expected"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(tt.code)
+		if err == nil || !strings.Contains(err.Error(), "uncaught ArgumentCountError: "+tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestEval_TypeDeclarations(t *testing.T) {
+	ctx, err := evalCode(`
+		namespace main
+
+		class Shape {}
+		class Square extends Shape {
+			public function grow(Int $by): self { $this }
+		}
+		function fib(int $n): int {
+			if $n < 2 { $n } else { fib($n - 1) + fib($n - 2) }
+		}
+		function area(Shape $shape, mixed $unit): string { "area" }
+		function log($message): void { $message }
+		$fib = fib(10)
+		$area = area(new Square(), 1)
+		$grown = (new Square())->grow(1) instanceof Square
+		$logged = log("x")
+		$typed = function(callable $f): bool { true }
+		$callable = $typed($typed)
+
+		function find(?Shape $shape, Int $limit = null): ?Shape { $shape }
+		$found = find(null)
+		$limited = find(new Shape(), null) instanceof Shape
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "fib", "55")
+	checkContextVariable(t, ctx, "area", "'area'")
+	checkContextVariable(t, ctx, "grown", "true")
+	checkContextVariable(t, ctx, "logged", "null")
+	checkContextVariable(t, ctx, "callable", "true")
+	checkContextVariable(t, ctx, "found", "null")
+	checkContextVariable(t, ctx, "limited", "true")
+}
+
+func TestEval_TypeErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{`function fib(int $n): int { $n }
+		fib("1")`, "fib(): argument #1 ($n) must be of type int, String given"},
+		{`function name(): string { 1 }
+		name()`, "name(): return value must be of type string, Int given"},
+		{`class Shape {}
+		function area($unit, Shape $shape) { 1 }
+		area(1, 2)`, "area(): argument #2 ($shape) must be of type Shape, Int given"},
+		{`class A { public function m(): self { 1 } }
+		(new A())->m()`, "A->m(): return value must be of type self, Int given"},
+		{`function f(?int $n) { $n }
+		f("1")`, "f(): argument #1 ($n) must be of type ?int, String given"},
+		{`function f(int $n = 1) { $n }
+		f(null)`, "f(): argument #1 ($n) must be of type int, Null given"},
+		{`function f(Missing $m) { $m }
+		f(1)`, "Missing is not a known type"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(tt.code)
+		if err == nil || !strings.Contains(err.Error(), "uncaught TypeError: "+tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, err)
+		}
+	}
+}
+
+func TestEval_Variadic(t *testing.T) {
+	ctx, err := evalCode(`
+		function sum(Int ...$nums) {
+			$total = 0
+			foreach $nums as $n { $total = $total + $n }
+			$total
+		}
+		function rest($first, ...$others) { $others->length() }
+		$xs = [4, 5]
+		$none = sum()
+		$some = sum(1, 2, 3)
+		$unpacked = sum(1, ...$xs, ...[6])
+		$rest = rest(...$xs)
+		$internal = try { println(...$xs) } catch (Error $e) { "error" }
+		$typed = try { sum(1, "a") } catch (TypeError $e) { $e->getMessage() }
+		$notArray = try { sum(...1) } catch (TypeError $e) { $e->getMessage() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "none", "0")
+	checkContextVariable(t, ctx, "some", "6")
+	checkContextVariable(t, ctx, "unpacked", "16")
+	checkContextVariable(t, ctx, "rest", "1")
+	checkContextVariable(t, ctx, "typed", "'sum(): argument #2 ($nums) must be of type Int, String given'")
+	checkContextVariable(t, ctx, "notArray", "'only arrays can be unpacked, Int given'")
+}
+
+func TestEval_NamedArgs(t *testing.T) {
+	ctx, err := evalCode(`
+		function makeUser($name, $admin = false, $role = "user") {
+			$name + ":" + $role
+		}
+		class Point {
+			public $sum = 0
+
+			public function __construct($x, $y = 0) { $this->sum = $x + $y }
+		}
+		$named = makeUser(role: "root", name: "x")
+		$mixed = makeUser("y", role: "dev")
+		$defaults = makeUser(name: "z")
+		$constructed = (new Point(y: 10, x: 1))->sum
+		$unknown = try { makeUser(nam: "x") } catch (Error $e) { $e->getMessage() }
+		$twice = try { makeUser("x", name: "z") } catch (Error $e) { $e->getMessage() }
+		$missing = try { makeUser(role: "x") } catch (ArgumentCountError $e) { $e->getMessage() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "named", "'x:root'")
+	checkContextVariable(t, ctx, "mixed", "'y:dev'")
+	checkContextVariable(t, ctx, "defaults", "'z:user'")
+	checkContextVariable(t, ctx, "constructed", "11")
+	checkContextVariable(t, ctx, "unknown", "'unknown named argument $nam'")
+	checkContextVariable(t, ctx, "twice", "'named argument $name overwrites previous argument'")
+	checkContextVariable(t, ctx, "missing", "'makeUser(): argument #1 ($name) not passed'")
+}
@@ -0,0 +1,142 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestEval_While(t *testing.T) {
+	ctx, err := evalCode(`
+		$i = 0
+		$sum = 0
+		while $i < 5 {
+			$sum = $sum + $i
+			$i = $i + 1
+		}
+		$never = 0
+		$result = while ($never > 0) { $never = 1 }
+
+		function firstPowerOver($limit) {
+			$n = 1
+			while true {
+				$n = $n * 2
+				if $n > $limit { return $n }
+			}
+		}
+		$power = firstPowerOver(100)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "sum", "10")
+	checkContextVariable(t, ctx, "never", "0")
+	checkContextVariable(t, ctx, "result", "null")
+	checkContextVariable(t, ctx, "power", "128")
+}
+
+func TestEval_DoWhile(t *testing.T) {
+	ctx, err := evalCode(`
+		$once = 10
+		do {
+			$once = $once + 1
+		} while ($once < 5)
+
+		$count = 0
+		do { $count = $count + 1 }
+		while $count < 3
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "once", "11")
+	checkContextVariable(t, ctx, "count", "3")
+}
+
+func TestEval_For(t *testing.T) {
+	ctx, err := evalCode(`
+		$steps = 0
+		for ($i = 0, $j = 10; $i < $j; $i = $i + 1, $j = $j - 1) {
+			$steps = $steps + 1
+		}
+		$sum = 0
+		for $k = 1; $k < 4; $k = $k + 1 {
+			$sum = $sum + $k
+		}
+
+		function firstOver($n, $limit) {
+			for (;;) {
+				$n = $n * 3
+				if $n > $limit { return $n }
+			}
+		}
+		$over = firstOver(1, 50)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "steps", "5")
+	checkContextVariable(t, ctx, "i", "5")
+	checkContextVariable(t, ctx, "j", "5")
+	checkContextVariable(t, ctx, "sum", "6")
+	checkContextVariable(t, ctx, "over", "81")
+}
+
+func TestEval_BreakContinue(t *testing.T) {
+	ctx, err := evalCode(`
+		$i = 0
+		while true {
+			$i = $i + 1
+			if $i == 3 { break }
+		}
+		$odd = 0
+		for ($j = 0; $j < 6; $j = $j + 1) {
+			if $j % 2 == 0 { continue }
+			$odd = $odd + 1
+		}
+		$pairs = 0
+		foreach [1, 2, 3] as $a {
+			foreach [1, 2, 3] as $b {
+				if $b == 2 { continue 2 }
+				if $a == 3 { break 2 }
+				$pairs = $pairs + 1
+			}
+		}
+		$k = 0
+		do {
+			$k = $k + 1
+			$caught = try {
+				throw new Exception("skip")
+			} catch (Exception $e) {
+				continue
+			}
+		} while $k < 4
+
+		function firstOver($limit) {
+			foreach [1, 5, 10] as $n {
+				if $n > $limit { return $n }
+			}
+		}
+		$over = firstOver(4)
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "i", "3")
+	checkContextVariable(t, ctx, "odd", "3")
+	checkContextVariable(t, ctx, "pairs", "2")
+	checkContextVariable(t, ctx, "k", "4")
+	checkContextVariable(t, ctx, "over", "5")
+}
+
+func TestEval_ForeachNonArray(t *testing.T) {
+	ctx, err := evalCode(`
+		$caught = try {
+			foreach 5 as $x {}
+		} catch (TypeError $e) {
+			$e->getMessage()
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "caught", "'foreach expects Array, Int given'")
+}
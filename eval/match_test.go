@@ -0,0 +1,53 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval_Match(t *testing.T) {
+	ctx, err := evalCode(`
+		class Animal {}
+		class Dog extends Animal {}
+
+		function describe($x) {
+			match ($x) {
+				1, 2 => "low",
+				Int if $x > 100 => "big"
+				Int => "int",
+				Animal => "animal",
+				default => "other"
+			}
+		}
+		$low = describe(2)
+		$big = describe(500)
+		$int = describe(7)
+		$animal = describe(new Dog())
+		$other = describe("2")
+		$string = match "b" { "a" => 1, "b" => 2 }
+		$strict = match "01" { "1" => "loose", "01" => "strict" }
+		$fallback = match (1) { default => "default", 1 => "one" }
+		$isAnimal = match (new Dog() instanceof Animal) { true => "yes", false => "no" }
+		$unhandled = try { match (5) { 1 => "one" } } catch (UnhandledMatchError $e) { $e->getMessage() }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "low", "'low'")
+	checkContextVariable(t, ctx, "big", "'big'")
+	checkContextVariable(t, ctx, "int", "'int'")
+	checkContextVariable(t, ctx, "animal", "'animal'")
+	checkContextVariable(t, ctx, "other", "'other'")
+	checkContextVariable(t, ctx, "string", "2")
+	checkContextVariable(t, ctx, "strict", "'strict'")
+	checkContextVariable(t, ctx, "fallback", "'one'")
+	checkContextVariable(t, ctx, "isAnimal", "'yes'")
+	checkContextVariable(t, ctx, "unhandled", "'unhandled match case 5'")
+}
+
+func TestEval_Match_Unhandled(t *testing.T) {
+	_, err := evalCode(`match ("x") { "y" => 1 }`)
+	if err == nil || !strings.Contains(err.Error(), `uncaught UnhandledMatchError: unhandled match case "x"`) {
+		t.Errorf("expected unhandled match error, got %v", err)
+	}
+}
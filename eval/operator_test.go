@@ -0,0 +1,523 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEval_CompoundAssignment(t *testing.T) {
+	ctx, err := evalCode(`
+		class Money {
+			public $amount = 0
+			public static $total = 1
+			public function __construct($amount) { $this->amount = $amount }
+			public function __add($other) { return new Money($this->amount + $other->amount) }
+		}
+		$x = 17
+		$x %= 5
+		$x -= 1
+		$x *= 6
+		$x /= 4
+		$x += 10
+		$pow = 2
+		$pow **= 10
+		$s = "a"
+		$s .= 1
+		$unset ??= "set"
+		$kept = "kept"
+		$kept ??= "other"
+
+		$calls = 0
+		$pick = function() use (&$calls) {
+			$calls += 1
+			return 1
+		}
+		$a = [1, 2, 3]
+		$a[$pick()] += 40
+		$a[3] = 4
+		$second = $a[1]
+		$fourth = $a[3]
+
+		$m = new Money(5)
+		$m += new Money(7)
+		$sum = $m->amount
+		$m->amount *= 2
+		$doubled = $m->amount
+		Money::$total += 2
+		$total = Money::$total
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "x", "11")
+	checkContextVariable(t, ctx, "pow", "1024")
+	checkContextVariable(t, ctx, "s", "'a1'")
+	checkContextVariable(t, ctx, "unset", "'set'")
+	checkContextVariable(t, ctx, "kept", "'kept'")
+	checkContextVariable(t, ctx, "calls", "1")
+	checkContextVariable(t, ctx, "second", "42")
+	checkContextVariable(t, ctx, "fourth", "4")
+	checkContextVariable(t, ctx, "sum", "12")
+	checkContextVariable(t, ctx, "doubled", "24")
+	checkContextVariable(t, ctx, "total", "3")
+}
+
+func TestEval_Concat(t *testing.T) {
+	ctx, err := evalCode(`
+		$n = 2
+		$s = "a" . $n + 1 . "b"
+		$s .= "c" . "d"
+		$null = "x" . null
+		$true = "x" . true
+		$false = "x" . false
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "s", "'a3bcd'")
+	checkContextVariable(t, ctx, "null", "'x'")
+	checkContextVariable(t, ctx, "true", "'x1'")
+	checkContextVariable(t, ctx, "false", "'x'")
+}
+
+func TestEval_ArrayValueSemantics(t *testing.T) {
+	ctx, err := evalCode(`
+		$a = [1, [2, 3]]
+		$b = $a
+		$b[0] = 9
+		$b[1][0] = 8
+		$first = $a[0]
+		$nested = $a[1][0]
+
+		function change($array) {
+			$array[0] = 7
+			return $array[0]
+		}
+		$changed = change($a)
+		$passed = $a[0]
+
+		$inner = [1]
+		$outer = [$inner]
+		$outer[0][0] = 5
+		$literal = $inner[0]
+
+		$captured = function() use ($a) {
+			$a[0] = 6
+		}
+		$captured()
+		$closure = $a[0]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "first", "1")
+	checkContextVariable(t, ctx, "nested", "2")
+	checkContextVariable(t, ctx, "changed", "7")
+	checkContextVariable(t, ctx, "passed", "1")
+	checkContextVariable(t, ctx, "literal", "1")
+	checkContextVariable(t, ctx, "closure", "1")
+}
+
+func TestEval_CompoundAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		code string
+		err  string
+	}{
+		{"$a = [1]\n$a[5] += 1\n", "index 5 is out of range"},
+		{"$s = \"abc\"\n$s[0] = \"x\"\n", "String does not support index assignment"},
+		{"$x = 2\n$x **= -1\n", "negative exponent -1 is not supported for Int"},
+	}
+	for _, tt := range tests {
+		_, err := evalCode(tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: expected error %q, got %v", tt.code, tt.err, err)
+		}
+	}
+}
+
+func TestEval_IncrementDecrement(t *testing.T) {
+	ctx, err := evalCode(`
+		class Counter {
+			public $count = 0
+			public static $created = 10
+		}
+		$i = 5
+		$postInc = $i++
+		$preInc = ++$i
+		$postDec = $i--
+		$preDec = --$i
+
+		$a = [1, 2]
+		$first = $a[0]++
+		$second = ++$a[1]
+		$a0 = $a[0]
+		$a1 = $a[1]
+
+		$c = new Counter()
+		$c->count++
+		$prop = ++$c->count
+		Counter::$created--
+		$created = Counter::$created
+
+		$loops = 0
+		for $k = 0; $k < 3; $k++ {
+			$loops += 1
+		}
+		$fresh++
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "postInc", "5")
+	checkContextVariable(t, ctx, "preInc", "7")
+	checkContextVariable(t, ctx, "postDec", "7")
+	checkContextVariable(t, ctx, "preDec", "5")
+	checkContextVariable(t, ctx, "i", "5")
+	checkContextVariable(t, ctx, "first", "1")
+	checkContextVariable(t, ctx, "second", "3")
+	checkContextVariable(t, ctx, "a0", "2")
+	checkContextVariable(t, ctx, "a1", "3")
+	checkContextVariable(t, ctx, "prop", "2")
+	checkContextVariable(t, ctx, "created", "9")
+	checkContextVariable(t, ctx, "loops", "3")
+	checkContextVariable(t, ctx, "fresh", "1")
+}
+
+func TestEval_PropertyIndex(t *testing.T) {
+	ctx, err := evalCode(`
+		class Bag {
+			public $a = [1, 2]
+			public static $counts = [0]
+			public function items() { $this->a }
+			public function first() { $this->a[0] }
+		}
+		$o = new Bag()
+		$read = $o->a[1]
+		$o->a[0] += 1
+		$o->a[0]++
+		++$o->a[1]
+		$first = $o->first()
+		$second = $o->items()[1]
+		Bag::$counts[0] += 5
+		$static = Bag::$counts[0]
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "read", "2")
+	checkContextVariable(t, ctx, "first", "3")
+	checkContextVariable(t, ctx, "second", "3")
+	checkContextVariable(t, ctx, "static", "5")
+}
+
+func TestEval_UnaryOperators(t *testing.T) {
+	ctx, err := evalCode(`
+		class Vector {
+			public $x = 0
+			public function __construct($x) { $this->x = $x }
+			public function __neg() { return new Vector(-$this->x) }
+			public function __not() { return $this->x == 0 }
+			public function __bitnot() { return new Vector(~$this->x) }
+			public function isZero() { return $this->x == 0 }
+		}
+		$x = 5
+		$neg = -$x
+		$sub = $x-1
+		$bits = ~$x
+		$grouped = -(2 + 3) * 2
+		$plus = +"42"
+		$notFalse = !false
+		$notNull = !$undefined
+		$notInt = !$x
+
+		$v = -new Vector(4)
+		$vx = $v->x
+		$zero = !new Vector(0)
+		$w = ~new Vector(1)
+		$wx = $w->x
+		$call = !(new Vector(1))->isZero()
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "neg", "-5")
+	checkContextVariable(t, ctx, "sub", "4")
+	checkContextVariable(t, ctx, "bits", "-6")
+	checkContextVariable(t, ctx, "grouped", "-10")
+	checkContextVariable(t, ctx, "plus", "42")
+	checkContextVariable(t, ctx, "notFalse", "true")
+	checkContextVariable(t, ctx, "notNull", "true")
+	checkContextVariable(t, ctx, "notInt", "false")
+	checkContextVariable(t, ctx, "vx", "-4")
+	checkContextVariable(t, ctx, "zero", "true")
+	checkContextVariable(t, ctx, "wx", "-2")
+	checkContextVariable(t, ctx, "call", "true")
+
+	_, err = evalCode("$s = -\"abc\"\n")
+	if err == nil || !strings.Contains(err.Error(), "operator - (method __neg) is not defined on type String") {
+		t.Errorf("expected error for negating a String, got %v", err)
+	}
+}
+
+func TestEval_LogicalOperators(t *testing.T) {
+	ctx, err := evalCode(`
+		$calls = 0
+		$touch = function() use (&$calls) {
+			$calls++
+			return true
+		}
+		$andShort = false && $touch()
+		$orShort = true || $touch()
+		$both = true && $touch()
+		$mixed = 1 < 2 && 2 < 3 || false
+		$truthy = 1 && 2
+		$word = true and false
+		$grouped = (true and false)
+		$either = (true xor true)
+		$orWord = false or true
+
+		$i = 0
+		while $i < 10 && !($i == 3) {
+			$i++
+		}
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "calls", "1")
+	checkContextVariable(t, ctx, "andShort", "false")
+	checkContextVariable(t, ctx, "orShort", "true")
+	checkContextVariable(t, ctx, "both", "true")
+	checkContextVariable(t, ctx, "mixed", "true")
+	checkContextVariable(t, ctx, "truthy", "true")
+	checkContextVariable(t, ctx, "word", "true")
+	checkContextVariable(t, ctx, "grouped", "false")
+	checkContextVariable(t, ctx, "either", "false")
+	checkContextVariable(t, ctx, "orWord", "false")
+	checkContextVariable(t, ctx, "i", "3")
+
+	_, err = evalCode("class Opaque {}\n$x = true && new Opaque()\n")
+	if err == nil || !strings.Contains(err.Error(), "can not convert Opaque to Boolean") {
+		t.Errorf("expected error for non-boolean operand, got %v", err)
+	}
+}
+
+func TestEval_Coalesce(t *testing.T) {
+	ctx, err := evalCode(`
+		class Box {
+			public $inner = null
+			public $value = 0
+			public function __construct($value) { $this->value = $value }
+		}
+		$box = new Box(1)
+		$box->inner = new Box(2)
+		$a = [1, 2]
+
+		$undefined = $missing ?? "fallback"
+		$prop = $box->nope ?? "no property"
+		$nested = $box->inner->inner->value ?? "no inner"
+		$index = $a[5] ?? "no index"
+		$found = $a[1] ?? "unused"
+		$chain = $u ?? $v ?? "last"
+		$zero = 0 ?? "not null"
+
+		$calls = 0
+		$touch = function() use (&$calls) {
+			$calls++
+			return 1
+		}
+		$lazy = $a[0] ?? $touch()
+
+		$a[2] ??= 3
+		$appended = $a[2]
+		$box->value ??= 100
+		$kept = $box->value
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "undefined", "'fallback'")
+	checkContextVariable(t, ctx, "prop", "'no property'")
+	checkContextVariable(t, ctx, "nested", "'no inner'")
+	checkContextVariable(t, ctx, "index", "'no index'")
+	checkContextVariable(t, ctx, "found", "2")
+	checkContextVariable(t, ctx, "chain", "'last'")
+	checkContextVariable(t, ctx, "zero", "0")
+	checkContextVariable(t, ctx, "lazy", "1")
+	checkContextVariable(t, ctx, "calls", "0")
+	checkContextVariable(t, ctx, "appended", "3")
+	checkContextVariable(t, ctx, "kept", "1")
+}
+
+func TestEval_Nullsafe(t *testing.T) {
+	ctx, err := evalCode(`
+		class Node {
+			public $next = null
+			public $name = ""
+			public function __construct($name) { $this->name = $name }
+			public function next() { return $this->next }
+			public function name() { return $this->name }
+		}
+		$head = new Node("head")
+		$head->next = new Node("tail")
+		$none = null
+
+		$tail = $head?->next()?->name()
+		$prop = $head->next?->name
+		$missing = $none?->next()?->name()
+		$shortCircuit = $none?->next()->name()
+		$deep = $head->next()->next()?->name() ?? "end"
+
+		$calls = 0
+		$touch = function() use (&$calls) {
+			$calls++
+			return 1
+		}
+		$skipped = $none?->name($touch())
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "tail", "'tail'")
+	checkContextVariable(t, ctx, "prop", "'tail'")
+	checkContextVariable(t, ctx, "missing", "null")
+	checkContextVariable(t, ctx, "shortCircuit", "null")
+	checkContextVariable(t, ctx, "deep", "'end'")
+	checkContextVariable(t, ctx, "calls", "0")
+
+	_, err = evalCode("$none = null\n$x = $none->name()\n")
+	if err == nil {
+		t.Error("expected error for a method call on null without ?->")
+	}
+}
+
+func TestEval_Comparisons(t *testing.T) {
+	ctx, err := evalCode(`
+		class Version {
+			public $major = 0
+			public function __construct($major) { $this->major = $major }
+			public function __compare($other) { return $this->major <=> $other->major }
+		}
+		$lt = 1 < 2
+		$le = 2 <= 2
+		$ge = 3 >= 4
+		$ne = 1 != 2
+		$loose = 1 == "1"
+		$strict = 1 === "1"
+		$notIdentical = 1 !== "1"
+		$leadingZero = "1" === "01"
+		$leadingSpace = " 1" === "1"
+		$looseLeadingZero = "1" == "01"
+		$strings = "abc" < "abd"
+		$numeric = "10" > 9
+		$nullFalse = null == false
+		$nullEmpty = null == ""
+		$arrays = [1, 2] == [1, "2"]
+		$arraysStrict = [1, 2] === [1, "2"]
+		$longer = [1, 2, 3] > [5, 6]
+		$spaceLess = 1 <=> 2
+		$spaceEqual = "a" <=> "a"
+		$spaceGreater = [1] <=> 0
+		$precedence = 1 + 1 == 2
+
+		$v1 = new Version(1)
+		$v2 = new Version(2)
+		$older = $v1 < $v2
+		$sameVersion = $v1 == new Version(1)
+		$sameObject = $v1 === new Version(1)
+		$itself = $v1 === $v1
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "lt", "true")
+	checkContextVariable(t, ctx, "le", "true")
+	checkContextVariable(t, ctx, "ge", "false")
+	checkContextVariable(t, ctx, "ne", "true")
+	checkContextVariable(t, ctx, "loose", "true")
+	checkContextVariable(t, ctx, "strict", "false")
+	checkContextVariable(t, ctx, "notIdentical", "true")
+	checkContextVariable(t, ctx, "leadingZero", "false")
+	checkContextVariable(t, ctx, "leadingSpace", "false")
+	checkContextVariable(t, ctx, "looseLeadingZero", "true")
+	checkContextVariable(t, ctx, "strings", "true")
+	checkContextVariable(t, ctx, "numeric", "true")
+	checkContextVariable(t, ctx, "nullFalse", "true")
+	checkContextVariable(t, ctx, "nullEmpty", "true")
+	checkContextVariable(t, ctx, "arrays", "true")
+	checkContextVariable(t, ctx, "arraysStrict", "false")
+	checkContextVariable(t, ctx, "longer", "true")
+	checkContextVariable(t, ctx, "spaceLess", "-1")
+	checkContextVariable(t, ctx, "spaceEqual", "0")
+	checkContextVariable(t, ctx, "spaceGreater", "1")
+	checkContextVariable(t, ctx, "precedence", "true")
+	checkContextVariable(t, ctx, "older", "true")
+	checkContextVariable(t, ctx, "sameVersion", "true")
+	checkContextVariable(t, ctx, "sameObject", "false")
+	checkContextVariable(t, ctx, "itself", "true")
+
+	_, err = evalCode("class Opaque {}\n$x = new Opaque() < new Opaque()\n")
+	if err == nil || !strings.Contains(err.Error(), "can not compare Opaque with Opaque") {
+		t.Errorf("expected error for comparing objects without __compare, got %v", err)
+	}
+}
+
+func TestEval_BitwiseAndPower(t *testing.T) {
+	ctx, err := evalCode(`
+		class Flags {
+			public $bits = 0
+			public function __construct($bits) { $this->bits = $bits }
+			public function __or($other) { return new Flags($this->bits | $other->bits) }
+			public function __shl($n) { return new Flags($this->bits << $n) }
+		}
+		$bitAnd = 6 & 3
+		$bitOr = 6 | 3
+		$bitXor = 6 ^ 3
+		$shl = 1 << 4
+		$shr = 256 >> 2
+		$pow = 2 ** 3 ** 2
+		$negPow = -2 ** 2
+		$powProduct = 2 ** 2 * 3
+		$mixed = 1 | 2 ^ 3 & 4
+		$shiftSum = 1 << 1 + 1
+		$withBoolean = 1 | 2 == 2
+		$booleanLeft = true ^ 3
+		$booleanShift = false << 2
+
+		$x = 5
+		$x &= 3
+		$x |= 8
+		$x ^= 1
+		$x <<= 2
+		$x >>= 1
+
+		$flags = new Flags(1) | new Flags(4)
+		$flagBits = $flags->bits
+		$flags <<= 1
+		$shifted = $flags->bits
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "bitAnd", "2")
+	checkContextVariable(t, ctx, "bitOr", "7")
+	checkContextVariable(t, ctx, "bitXor", "5")
+	checkContextVariable(t, ctx, "shl", "16")
+	checkContextVariable(t, ctx, "shr", "64")
+	checkContextVariable(t, ctx, "pow", "512")
+	checkContextVariable(t, ctx, "negPow", "-4")
+	checkContextVariable(t, ctx, "powProduct", "12")
+	checkContextVariable(t, ctx, "mixed", "3")
+	checkContextVariable(t, ctx, "shiftSum", "4")
+	checkContextVariable(t, ctx, "withBoolean", "1")
+	checkContextVariable(t, ctx, "booleanLeft", "2")
+	checkContextVariable(t, ctx, "booleanShift", "0")
+	checkContextVariable(t, ctx, "x", "16")
+	checkContextVariable(t, ctx, "flagBits", "5")
+	checkContextVariable(t, ctx, "shifted", "10")
+
+	_, err = evalCode("$x = 1 << -1\n")
+	if err == nil || !strings.Contains(err.Error(), "bit shift by negative number -1") {
+		t.Errorf("expected error for negative shift, got %v", err)
+	}
+}
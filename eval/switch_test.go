@@ -0,0 +1,48 @@
+package eval
+
+import (
+	"testing"
+)
+
+func TestEval_Switch(t *testing.T) {
+	ctx, err := evalCode(`
+		function grade($x) {
+			$out = ""
+			switch ($x) {
+			case 1:
+			case 2:
+				$out = "low"
+				break
+			case "3":
+				$out = "three, "
+			default:
+				$out = $out + "other"
+			}
+			return $out
+		}
+		$low = grade(2)
+		$loose = grade(3)
+		$other = grade(9)
+
+		$sum = 0
+		foreach [1, 2, 3, 4] as $i {
+			switch $i {
+			case 2:
+				continue 2
+			case 4:
+				break 2
+			}
+			$sum = $sum + $i
+		}
+		$none = "untouched"
+		switch "x" { case "y": $none = "touched" }
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContextVariable(t, ctx, "low", "'low'")
+	checkContextVariable(t, ctx, "loose", "'three, other'")
+	checkContextVariable(t, ctx, "other", "'other'")
+	checkContextVariable(t, ctx, "sum", "4")
+	checkContextVariable(t, ctx, "none", "'untouched'")
+}
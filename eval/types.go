@@ -1,6 +1,12 @@
 package eval
 
-import "github.com/pmukhin/gophp/object"
+import (
+	"errors"
+	"github.com/pmukhin/gophp/object"
+)
+
+// errShortCircuit stops evaluation of a fetch chain after `?->` met null
+var errShortCircuit = errors.New("nullsafe short circuit")
 
 // returnObject is a wrapper for returned objects
 // to make easier to recognize when function
@@ -14,3 +20,35 @@ func (returnObject) Class() object.Class { panic("this function should not ever
 
 // Id ...
 func (returnObject) Id() string { panic("not implemented") }
+
+// loopControl is a wrapper for break and continue,
+// it unwinds blocks up to the loop it's addressed to
+type loopControl struct {
+	isContinue bool
+	levels     int64
+}
+
+// Class ...
+func (loopControl) Class() object.Class { panic("this function should not ever be called") }
+
+// Id ...
+func (loopControl) Id() string { panic("not implemented") }
+
+// unwinds reports whether o stops execution of enclosing blocks
+func unwinds(o object.Object) bool {
+	switch o.(type) {
+	case returnObject, loopControl:
+		return true
+	}
+	return false
+}
+
+// skippedArg stands for a parameter skipped by named arguments,
+// it takes the default value
+type skippedArg struct{}
+
+// Class ...
+func (skippedArg) Class() object.Class { panic("this function should not ever be called") }
+
+// Id ...
+func (skippedArg) Id() string { panic("not implemented") }
@@ -21,13 +21,22 @@ func arrayToString(this Object, args ...Object) (Object, error) {
 	return &StringObject{Value: "[" + strings.Join(strList, ", ") + "]"}, nil
 }
 
+// arrayConstruct makes a new array of its arguments
+func arrayConstruct(this Object, args ...Object) (Object, error) {
+	return NewArray(args...)
+}
+
 func arrayLen(this Object, args ...Object) (Object, error) {
 	return &IntegerObject{Value: int64(len(this.(*ArrayObject).Values))}, nil
 }
 
+func arrayToBoolean(this Object, args ...Object) (Object, error) {
+	return NativeBool(len(this.(*ArrayObject).Values) > 0), nil
+}
+
 func arrayAppend(this Object, args ...Object) (Object, error) {
 	if len(args) == 0 {
-		return Null, fmt.Errorf("at least 1 argument expected")
+		return Null, Throw(ArgumentCountErrorClass, "at least 1 argument expected")
 	}
 	array := this.(*ArrayObject)
 	for _, a := range args {
@@ -36,19 +45,62 @@ func arrayAppend(this Object, args ...Object) (Object, error) {
 	return Null, nil
 }
 
+// arrayIndex returns a value of the array by its index
+func arrayIndex(this Object, args ...Object) (Object, error) {
+	a := this.(*ArrayObject)
+	if len(args) != 1 {
+		return Null, Throw(ArgumentCountErrorClass, "__index takes exactly one parameter, %d given", len(args))
+	}
+	i, e := ToInteger(args[0])
+	if e != nil {
+		return Null, e
+	}
+	if i.Value < 0 || i.Value >= int64(len(a.Values)) {
+		return Null, Throw(IndexErrorClass, "index %d is out of range", i.Value)
+	}
+	return a.Values[i.Value], nil
+}
+
+// arraySetIndex replaces a value of the array, setting the value right past the end appends it
+func arraySetIndex(this Object, args ...Object) (Object, error) {
+	a := this.(*ArrayObject)
+	if len(args) != 2 {
+		return Null, Throw(ArgumentCountErrorClass, "__setIndex takes exactly two parameters, %d given", len(args))
+	}
+	i, e := ToInteger(args[0])
+	if e != nil {
+		return Null, e
+	}
+	if i.Value < 0 || i.Value > int64(len(a.Values)) {
+		return Null, Throw(IndexErrorClass, "index %d is out of range", i.Value)
+	}
+	if i.Value == int64(len(a.Values)) {
+		a.Values = append(a.Values, args[1])
+	} else {
+		a.Values[i.Value] = args[1]
+	}
+	return args[1], nil
+}
+
 var (
 	arrayMethods = map[string]Method{
 		"__toString": newMethod(arrayToString, VisibilityPublic),
 
+		"__index":     newMethod(arrayIndex, VisibilityPublic),
+		"__setIndex":  newMethod(arraySetIndex, VisibilityPublic),
+		"__compare":   newMethod(compare, VisibilityPublic),
+		"__toBoolean": newMethod(arrayToBoolean, VisibilityPublic),
+
 		"length": newMethod(arrayLen, VisibilityPublic),
 		"append": newMethod(arrayAppend, VisibilityPublic),
 	}
 
 	arrayClass = &InternalClass{
-		name:      "Array",
-		final:     false,
-		abstract:  false,
-		methodSet: newMethodSet(arrayMethods),
+		name:        "Array",
+		final:       false,
+		abstract:    false,
+		constructor: newMethod(arrayConstruct, VisibilityPublic),
+		methodSet:   newMethodSet(arrayMethods),
 	}
 )
 
@@ -68,6 +120,20 @@ func NewArray(os ...Object) (Object, error) {
 	return array, nil
 }
 
+// CopyValue copies arrays, nested ones included, as they are values.
+// Other objects are shared
+func CopyValue(o Object) Object {
+	a, ok := o.(*ArrayObject)
+	if !ok {
+		return o
+	}
+	values := make([]Object, len(a.Values))
+	for i, v := range a.Values {
+		values[i] = CopyValue(v)
+	}
+	return &ArrayObject{Values: values}
+}
+
 type ArrayObject struct {
 	Values []Object
 }
@@ -1,11 +1,18 @@
 package object
 
 var (
+	booleanMethods = map[string]Method{
+		"__not":      newMethod(bNot, VisibilityPublic),
+		"__compare":  newMethod(compare, VisibilityPublic),
+		"__toInt":    newMethod(bToInt, VisibilityPublic),
+		"__toString": newMethod(bToString, VisibilityPublic),
+	}
+
 	BooleanClass = &InternalClass{
 		name:      "Boolean",
 		final:     true,
 		abstract:  false,
-		methodSet: newMethodSet(nil),
+		methodSet: newMethodSet(booleanMethods),
 	}
 
 	True  = &BooleanObject{Value: true}
@@ -23,3 +30,38 @@ func (BooleanObject) Class() Class {
 func (BooleanObject) Id() string {
 	panic("implement me")
 }
+
+func bNot(this Object, args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return Null, Throw(ArgumentCountErrorClass, "__not takes no parameters, %d given", len(args))
+	}
+	return NativeBool(!this.(*BooleanObject).Value), nil
+}
+
+// bToInt converts true to 1 and false to 0
+func bToInt(this Object, args ...Object) (Object, error) {
+	if this.(*BooleanObject).Value {
+		return &IntegerObject{Value: 1}, nil
+	}
+	return &IntegerObject{Value: 0}, nil
+}
+
+// bToString converts true to "1" and false to an empty string
+func bToString(this Object, args ...Object) (Object, error) {
+	if this.(*BooleanObject).Value {
+		return &StringObject{Value: "1"}, nil
+	}
+	return &StringObject{Value: ""}, nil
+}
+
+// NativeBool returns True or False object for b
+func NativeBool(b bool) *BooleanObject {
+	if b {
+		return True
+	}
+	return False
+}
+
+func registerBooleanConstants(ctx Context) {
+	ctx.SetGlobal(BooleanClass.name, BooleanClass)
+}
@@ -1,5 +1,11 @@
 package object
 
+import (
+	"fmt"
+	"github.com/pmukhin/gophp/ast"
+	"sort"
+)
+
 type Visibility uint8
 
 const (
@@ -8,16 +14,64 @@ const (
 	VisibilityPrivate
 )
 
+func (v Visibility) String() string {
+	switch v {
+	case VisibilityProtected:
+		return "protected"
+	case VisibilityPrivate:
+		return "private"
+	default:
+		return "public"
+	}
+}
+
 type Class interface {
+	// classes are objects as well, they're stored in globals table
+	Object
 	Name() string
 	Constructor() Method
 	SuperClass() Class
 	IsFinal() bool
 	IsAbstract() bool
+	Interfaces() []Class
 	Methods() MethodSet
 	StaticMethods() MethodSet
 }
 
+// classClass is the class of class values, e.g. `Foo` in `match ($class) { Foo => 1 }`
+var classClass = &InternalClass{
+	name:            "Class",
+	final:           true,
+	methodSet:       newMethodSet(nil),
+	staticMethodSet: newMethodSet(nil),
+}
+
+// IsSubclassOf reports whether class is parent or extends it
+func IsSubclassOf(class, parent Class) bool {
+	for ; class != nil; class = class.SuperClass() {
+		if class == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInstanceOf reports whether objects of class are instances of target,
+// which is either the class itself, one of its parents or interfaces
+func IsInstanceOf(class, target Class) bool {
+	for ; class != nil; class = class.SuperClass() {
+		if class == target {
+			return true
+		}
+		for _, iface := range class.Interfaces() {
+			if IsInstanceOf(iface, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type InternalConstructor func(value interface{}) (Object, error)
 
 type Method interface {
@@ -40,71 +94,227 @@ type Object interface {
 	Id() string
 }
 
-func NewUserClass(final bool, abstract bool, methodSet MethodSet, propertySet map[string]Object) Class {
+func NewUserClass(name string, superClass Class, interfaces []Class, final bool, abstract bool, methods map[string]Method, propertySet map[string]Object) *UserClass {
+	ms := &methodSet{nameMap: methods}
+	sms := &methodSet{nameMap: make(map[string]Method)}
+	if superClass != nil {
+		ms.parent = superClass.Methods()
+		sms.parent = superClass.StaticMethods()
+	}
 	return &UserClass{
-		final:           final,
-		abstract:        abstract,
-		methodSet:       methodSet,
-		staticMethodSet: nil,
-		propertySet:     propertySet,
+		name:             name,
+		superClass:       superClass,
+		interfaces:       interfaces,
+		final:            final,
+		abstract:         abstract,
+		methodSet:        ms,
+		staticMethodSet:  sms,
+		propertySet:      propertySet,
+		staticProperties: make(map[string]Object),
+		visibilities:     make(map[string]Visibility),
+		constants:        make(map[string]Object),
 	}
 }
 
 type UserClass struct {
-	final           bool
-	abstract        bool
-	methodSet       MethodSet
-	staticMethodSet MethodSet
-	propertySet     map[string]Object
+	name             string
+	superClass       Class
+	interfaces       []Class
+	final            bool
+	abstract         bool
+	methodSet        MethodSet
+	staticMethodSet  *methodSet
+	propertySet      map[string]Object
+	staticProperties map[string]Object
+	visibilities     map[string]Visibility
+	constants        map[string]Object
+}
+
+func (UserClass) Class() Class {
+	return classClass
+}
+
+// Id is the name, names of classes are unique
+func (c UserClass) Id() string {
+	return c.name
+}
+
+func (c UserClass) Name() string {
+	return c.name
+}
+
+// Constructor returns __construct method or nil if the class has none
+func (c UserClass) Constructor() Method {
+	return c.methodSet.Find("__construct")
+}
+
+func (c UserClass) SuperClass() Class {
+	return c.superClass
+}
+
+func (c UserClass) IsFinal() bool {
+	return c.final
 }
 
-func (UserClass) Name() string {
-	panic("implement me")
+func (c UserClass) IsAbstract() bool {
+	return c.abstract
 }
 
-func (UserClass) Constructor() Method {
-	panic("implement me")
+// Interfaces returns interfaces listed in `implements` of the class
+func (c UserClass) Interfaces() []Class {
+	return c.interfaces
 }
 
-func (UserClass) SuperClass() Class {
-	panic("implement me")
+// Methods returns methods of the class and all of its parents
+func (c UserClass) Methods() MethodSet {
+	return c.methodSet
 }
 
-func (UserClass) IsFinal() bool {
-	panic("implement me")
+// StaticMethods returns static methods of the class and all of its parents
+func (c UserClass) StaticMethods() MethodSet {
+	return c.staticMethodSet
 }
 
-func (UserClass) IsAbstract() bool {
-	panic("implement me")
+// DeclareStaticMethod adds a static method to the class
+func (c *UserClass) DeclareStaticMethod(name string, m Method) {
+	c.staticMethodSet.nameMap[name] = m
 }
 
-func (UserClass) Methods() MethodSet {
-	panic("implement me")
+// DeclarePropertyVisibility sets visibility of property name declared in the class
+func (c *UserClass) DeclarePropertyVisibility(name string, vis Visibility) {
+	c.visibilities[name] = vis
+}
+
+// PropertyVisibility returns visibility of property name and the class declaring it,
+// properties which are not declared (e.g. assigned dynamically) are public
+func (c *UserClass) PropertyVisibility(name string) (Visibility, Class) {
+	for class := c; class != nil; {
+		if vis, ok := class.visibilities[name]; ok {
+			return vis, class
+		}
+		class, _ = class.superClass.(*UserClass)
+	}
+	return VisibilityPublic, c
 }
 
-func (UserClass) StaticMethods() MethodSet {
-	panic("implement me")
+// DeclareStaticProperty adds a static property to the class, it shadows
+// the property of the same name declared in a parent
+func (c *UserClass) DeclareStaticProperty(name string, value Object) {
+	c.staticProperties[name] = value
+}
+
+// StaticProperty returns the value of static property name
+// declared in the class or one of its parents
+func (c *UserClass) StaticProperty(name string) (Object, bool) {
+	if owner := c.staticPropertyOwner(name); owner != nil {
+		return owner.staticProperties[name], true
+	}
+	return nil, false
+}
+
+// SetStaticProperty sets the value of static property name,
+// it returns false if the property is not declared
+func (c *UserClass) SetStaticProperty(name string, value Object) bool {
+	owner := c.staticPropertyOwner(name)
+	if owner == nil {
+		return false
+	}
+	owner.staticProperties[name] = value
+	return true
+}
+
+// staticPropertyOwner returns the class which declares static property name
+func (c *UserClass) staticPropertyOwner(name string) *UserClass {
+	for class := c; class != nil; {
+		if _, ok := class.staticProperties[name]; ok {
+			return class
+		}
+		class, _ = class.superClass.(*UserClass)
+	}
+	return nil
+}
+
+// DeclareConstant adds a constant to the class
+func (c *UserClass) DeclareConstant(name string, value Object) error {
+	if _, ok := c.constants[name]; ok {
+		return Throw(ErrorClass, "can not redeclare constant %s::%s", c.name, name)
+	}
+	c.constants[name] = value
+	return nil
+}
+
+// Constant returns the value of constant name declared in the class or one of its parents
+func (c *UserClass) Constant(name string) (Object, bool) {
+	for class := c; class != nil; {
+		if value, ok := class.constants[name]; ok {
+			return value, true
+		}
+		class, _ = class.superClass.(*UserClass)
+	}
+	return nil, false
+}
+
+// UserObject is an instance of a user class
+type UserObject struct {
+	class      Class
+	properties map[string]Object
+}
+
+// NewUserObject creates an instance of class with default property values,
+// properties declared in subclasses override the ones of their parents
+func NewUserObject(class *UserClass) *UserObject {
+	o := &UserObject{class: class, properties: make(map[string]Object, len(class.propertySet))}
+	o.setDefaults(class)
+	return o
+}
+
+func (o *UserObject) setDefaults(class *UserClass) {
+	if parent, ok := class.superClass.(*UserClass); ok {
+		o.setDefaults(parent)
+	}
+	for name, value := range class.propertySet {
+		// every instance gets its own copy of default arrays
+		o.properties[name] = CopyValue(value)
+	}
+}
+
+func (o *UserObject) Class() Class { return o.class }
+
+func (o *UserObject) Id() string { return fmt.Sprintf("%p", o) }
+
+// Property returns the value of property name
+func (o *UserObject) Property(name string) (Object, bool) {
+	v, ok := o.properties[name]
+	return v, ok
+}
+
+// SetProperty sets the value of property name
+func (o *UserObject) SetProperty(name string, value Object) {
+	o.properties[name] = value
 }
 
 type InternalClass struct {
 	name                string
+	superClass          Class
+	interfaces          []Class
 	final               bool
 	abstract            bool
 	constructor         Method
 	internalConstructor InternalConstructor
 	methodSet           MethodSet
+	staticMethodSet     MethodSet
 }
 
 func (c InternalClass) InternalConstructor(value interface{}) (Object, error) {
 	return c.internalConstructor(value)
 }
 
-func (c InternalClass) Class() Class {
-	panic("implement me")
+func (InternalClass) Class() Class {
+	return classClass
 }
 
 func (c InternalClass) Id() string {
-	panic("implement me")
+	return c.name
 }
 
 func (c InternalClass) Name() string {
@@ -115,8 +325,8 @@ func (c InternalClass) Constructor() Method {
 	return c.constructor
 }
 
-func (InternalClass) SuperClass() Class {
-	panic("implement me")
+func (c InternalClass) SuperClass() Class {
+	return c.superClass
 }
 
 func (c InternalClass) IsFinal() bool {
@@ -127,24 +337,64 @@ func (c InternalClass) IsAbstract() bool {
 	return c.abstract
 }
 
+func (c InternalClass) Interfaces() []Class {
+	return c.interfaces
+}
+
 func (c InternalClass) Methods() MethodSet {
 	return c.methodSet
 }
 
-func (InternalClass) StaticMethods() MethodSet {
-	panic("implement me")
+func (c InternalClass) StaticMethods() MethodSet {
+	if c.staticMethodSet == nil {
+		return newMethodSet(nil)
+	}
+	return c.staticMethodSet
 }
 
 type methodSet struct {
 	nameMap map[string]Method
+	parent  MethodSet
 }
 
+// Find looks for a method by name, then in the parent set if there's one
 func (ms methodSet) Find(name string) Method {
-	return ms.nameMap[name]
+	if m, ok := ms.nameMap[name]; ok {
+		return m
+	}
+	if ms.parent != nil {
+		return ms.parent.Find(name)
+	}
+	return nil
 }
 
-func (methodSet) All() []Method {
-	panic("implement me")
+// All returns methods of the set and the ones of its parents which are not overridden
+func (ms methodSet) All() []Method {
+	names := ms.names()
+	all := make([]Method, len(names))
+	for i, name := range names {
+		all[i] = ms.Find(name)
+	}
+	return all
+}
+
+// names returns sorted names of all methods
+func (ms methodSet) names() []string {
+	seen := make(map[string]bool, len(ms.nameMap))
+	for name := range ms.nameMap {
+		seen[name] = true
+	}
+	if parent, ok := ms.parent.(*methodSet); ok {
+		for _, name := range parent.names() {
+			seen[name] = true
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func newMethodSet(nameMap map[string]Method) MethodSet {
@@ -155,6 +405,7 @@ type method struct {
 	name string
 	f    func(this Object, args ...Object) (Object, error)
 	vis  Visibility
+	args []*ast.Arg
 }
 
 func (m method) Call(this Object, args ...Object) (Object, error) {
@@ -165,6 +416,170 @@ func (m method) Visibility() Visibility {
 	return m.vis
 }
 
+// Args returns the declared parameters of the method, used to place named arguments
+func (m method) Args() []*ast.Arg {
+	return m.args
+}
+
 func newMethod(f func(this Object, args ...Object) (Object, error), vis Visibility) Method {
 	return &method{f: f, vis: vis}
 }
+
+// newMethodWithArgs makes a built-in method that accepts named arguments for args
+func newMethodWithArgs(f func(this Object, args ...Object) (Object, error), vis Visibility, args ...*ast.Arg) Method {
+	return &method{f: f, vis: vis, args: args}
+}
+
+// UserMethod is a method declared in a user class or interface body,
+// its body is evaluated by invoke
+type UserMethod struct {
+	class    Class
+	name     string
+	vis      Visibility
+	abstract bool
+	static   bool
+	function FunctionObject
+	invoke   func(this Object, args ...Object) (Object, error)
+}
+
+func (m UserMethod) Call(this Object, args ...Object) (Object, error) {
+	if m.abstract {
+		return Null, Throw(ErrorClass, "can not call abstract method %s::%s", m.class.Name(), m.name)
+	}
+	return m.invoke(this, args...)
+}
+
+func (m UserMethod) Visibility() Visibility {
+	return m.vis
+}
+
+func (m UserMethod) Name() string {
+	return m.name
+}
+
+// DeclaringClass returns the class or interface the method is declared in
+func (m UserMethod) DeclaringClass() Class {
+	return m.class
+}
+
+func (m UserMethod) IsAbstract() bool {
+	return m.abstract
+}
+
+func (m UserMethod) IsStatic() bool {
+	return m.static
+}
+
+// Function returns declaration of the method
+func (m UserMethod) Function() FunctionObject {
+	return m.function
+}
+
+func NewUserMethod(class Class, name string, vis Visibility, function FunctionObject, invoke func(this Object, args ...Object) (Object, error)) Method {
+	return &UserMethod{class: class, name: name, vis: vis, function: function, invoke: invoke}
+}
+
+// NewStaticMethod makes a method which is called with the class it is called on instead of an object
+func NewStaticMethod(class Class, name string, vis Visibility, function FunctionObject, invoke func(this Object, args ...Object) (Object, error)) Method {
+	return &UserMethod{class: class, name: name, vis: vis, static: true, function: function, invoke: invoke}
+}
+
+// NewAbstractMethod makes a method without a body, calling it is an error
+func NewAbstractMethod(class Class, name string, vis Visibility, function FunctionObject) Method {
+	return &UserMethod{class: class, name: name, vis: vis, abstract: true, function: function}
+}
+
+// Interface declares methods which classes implementing it must have
+type Interface struct {
+	name      string
+	parents   []Class
+	methodSet MethodSet
+}
+
+func NewInterface(name string, parents []Class, methods map[string]Method) *Interface {
+	return &Interface{name: name, parents: parents, methodSet: newMethodSet(methods)}
+}
+
+func (Interface) Class() Class {
+	return classClass
+}
+
+func (i Interface) Id() string {
+	return i.name
+}
+
+func (i Interface) Name() string {
+	return i.name
+}
+
+func (Interface) Constructor() Method {
+	return nil
+}
+
+func (Interface) SuperClass() Class {
+	return nil
+}
+
+func (Interface) IsFinal() bool {
+	return false
+}
+
+func (Interface) IsAbstract() bool {
+	return true
+}
+
+// Interfaces returns interfaces listed in `extends` of the interface
+func (i Interface) Interfaces() []Class {
+	return i.parents
+}
+
+// Methods returns methods declared in the interface itself
+func (i Interface) Methods() MethodSet {
+	return i.methodSet
+}
+
+func (Interface) StaticMethods() MethodSet {
+	return newMethodSet(nil)
+}
+
+// Trait is a set of method and property declarations copied into classes using it
+type Trait struct {
+	name       string
+	methods    map[string]*ast.MethodDeclarationExpression
+	properties []*ast.PropertyDeclarationExpression
+}
+
+func NewTrait(name string, methods map[string]*ast.MethodDeclarationExpression, properties []*ast.PropertyDeclarationExpression) *Trait {
+	return &Trait{name: name, methods: methods, properties: properties}
+}
+
+func (Trait) Class() Class {
+	return classClass
+}
+
+func (t Trait) Id() string {
+	return t.name
+}
+
+func (t Trait) Name() string {
+	return t.name
+}
+
+// Method returns declaration of method name or nil if the trait has none
+func (t Trait) Method(name string) *ast.MethodDeclarationExpression {
+	return t.methods[name]
+}
+
+// MethodNames returns sorted names of all methods of the trait
+func (t Trait) MethodNames() []string {
+	names := make([]string, 0, len(t.methods))
+	for name := range t.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (t Trait) Properties() []*ast.PropertyDeclarationExpression {
+	return t.properties
+}
@@ -0,0 +1,123 @@
+package object
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compare implements `__compare` of built-in types, it returns -1, 0 or 1 like `<=>` does.
+// Null and Boolean are compared as booleans, arrays by length and then element by element,
+// an array is greater than any other value, numbers and numeric strings are compared numerically
+// and the rest as strings
+func compare(this Object, args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return Null, Throw(ArgumentCountErrorClass, "__compare takes exactly one parameter, %d given", len(args))
+	}
+	result, err := compareValues(this, args[0])
+	if err != nil {
+		return Null, err
+	}
+	return &IntegerObject{Value: result}, nil
+}
+
+func compareValues(l, r Object) (int64, error) {
+	_, lNull := l.(*NullObject)
+	_, rNull := r.(*NullObject)
+	ls, lString := l.(*StringObject)
+	rs, rString := r.(*StringObject)
+	// null is an empty string next to a string
+	if lNull && rString {
+		return compareStrings("", rs.Value), nil
+	}
+	if lString && rNull {
+		return compareStrings(ls.Value, ""), nil
+	}
+	_, lBool := l.(*BooleanObject)
+	_, rBool := r.(*BooleanObject)
+	if lNull || rNull || lBool || rBool {
+		return compareBooleans(l, r)
+	}
+
+	la, lArray := l.(*ArrayObject)
+	ra, rArray := r.(*ArrayObject)
+	switch {
+	case lArray && rArray:
+		return compareArrays(la, ra)
+	case lArray:
+		return 1, nil
+	case rArray:
+		return -1, nil
+	}
+
+	ln, lNumber := numericValue(l)
+	rn, rNumber := numericValue(r)
+	if lNumber && rNumber {
+		return compareInts(ln, rn), nil
+	}
+	lStr, err := ToString(l)
+	if err != nil {
+		return 0, err
+	}
+	rStr, err := ToString(r)
+	if err != nil {
+		return 0, err
+	}
+	return compareStrings(lStr.Value, rStr.Value), nil
+}
+
+// numericValue returns the value of Int or numeric String
+func numericValue(o Object) (int64, bool) {
+	switch o := o.(type) {
+	case *IntegerObject:
+		return o.Value, true
+	case *StringObject:
+		i, err := strconv.ParseInt(strings.TrimSpace(o.Value), 10, 64)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func compareInts(l, r int64) int64 {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	}
+	return 0
+}
+
+func compareStrings(l, r string) int64 {
+	return int64(strings.Compare(l, r))
+}
+
+func compareBooleans(l, r Object) (int64, error) {
+	lb, err := ToBoolean(l)
+	if err != nil {
+		return 0, err
+	}
+	rb, err := ToBoolean(r)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case lb == rb:
+		return 0, nil
+	case rb:
+		return -1, nil
+	}
+	return 1, nil
+}
+
+func compareArrays(l, r *ArrayObject) (int64, error) {
+	if c := compareInts(int64(len(l.Values)), int64(len(r.Values))); c != 0 {
+		return c, nil
+	}
+	for i := range l.Values {
+		c, err := Compare(l.Values[i], r.Values[i])
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+	return 0, nil
+}
@@ -1,28 +1,37 @@
 package object
 
-import (
-	"fmt"
-)
-
 type localStorage struct {
-	vars   map[string]Object
+	vars map[string]Object
+	// variables bound by reference to the ones of other storages
+	refs   map[string]*localStorage
 	parent *localStorage
 }
 
 func (l *localStorage) isSet(name string) bool {
-	_, ok := l.vars[name]
+	if _, ok := l.vars[name]; ok {
+		return true
+	}
+	_, ok := l.refs[name]
 	return ok
 }
 
+// owner returns the storage where name is set, nil if it's set nowhere
+func (l *localStorage) owner(name string) *localStorage {
+	s := l
+	for s != nil && !s.isSet(name) {
+		s = s.parent
+	}
+	return s
+}
+
 func (l *localStorage) Set(name string, o Object) error {
 	// first let'par try to found this name somewhere in the outer context
-	par := l
-	for par != nil && !par.isSet(name) {
-		par = par.parent
-	}
+	par := l.owner(name)
 	// if it'par there par would not be null
 	if par == nil {
 		l.vars[name] = o
+	} else if ref, ok := par.refs[name]; ok {
+		return ref.Set(name, o)
 	} else {
 		par.vars[name] = o
 	}
@@ -31,14 +40,47 @@ func (l *localStorage) Set(name string, o Object) error {
 }
 
 func (l *localStorage) Get(name string) (Object, error) {
-	s := l
-	for s != nil && !s.isSet(name) {
-		s = s.parent
+	s := l.owner(name)
+	if s == nil {
+		return Null, nil
+	}
+	if ref, ok := s.refs[name]; ok {
+		return ref.Get(name)
 	}
-	if s != nil {
-		return s.vars[name], nil
+	return s.vars[name], nil
+}
+
+// Capture copies variable name visible from scope into l, or binds it
+// to the variable of scope by reference which is declared as null if it's unset
+func (l *localStorage) Capture(name string, scope *localStorage, byRef bool) {
+	if !byRef {
+		value, _ := scope.Get(name)
+		l.vars[name] = CopyValue(value)
+		return
 	}
-	return Null, nil
+	owner := scope.owner(name)
+	if owner == nil {
+		owner = scope
+		owner.vars[name] = Null
+	}
+	l.refs[name] = owner
+}
+
+// Copy makes a storage with the same variables and references as l,
+// changes of variables held by value don't affect l
+func (l *localStorage) Copy() *localStorage {
+	if l == nil {
+		return nil
+	}
+	c := newLocalStorage()
+	for name, value := range l.vars {
+		c.vars[name] = value
+	}
+	for name, ref := range l.refs {
+		c.refs[name] = ref
+	}
+	c.parent = l.parent
+	return c
 }
 
 func (l *localStorage) SetParent(s *localStorage) {
@@ -46,7 +88,12 @@ func (l *localStorage) SetParent(s *localStorage) {
 }
 
 func newLocalStorage() *localStorage {
-	return &localStorage{vars: make(map[string]Object)}
+	return &localStorage{vars: make(map[string]Object), refs: make(map[string]*localStorage)}
+}
+
+// NewScope makes an empty scope, closures keep their captured variables in it
+func NewScope() *localStorage {
+	return newLocalStorage()
 }
 
 type Context interface {
@@ -58,20 +105,45 @@ type Context interface {
 	GetContextVar(string) (Object, error)
 	SetContextVar(string, Object) error
 	Scope() *localStorage
+
+	// class which method is being evaluated
+	ScopeClass() Class
+	SetScopeClass(Class)
+	// class the method is called on, it's what `static::` refers to
+	CalledClass() Class
+	SetCalledClass(Class)
 }
 
 type context struct {
 	scope        *localStorage
 	globalsTable map[string]Object
+	class        Class
+	calledClass  Class
 }
 
 func (c *context) Scope() *localStorage {
 	return c.scope
 }
 
+func (c *context) ScopeClass() Class {
+	return c.class
+}
+
+func (c *context) SetScopeClass(class Class) {
+	c.class = class
+}
+
+func (c *context) CalledClass() Class {
+	return c.calledClass
+}
+
+func (c *context) SetCalledClass(class Class) {
+	c.calledClass = class
+}
+
 func (c *context) SetGlobal(name string, value Object) error {
 	if _, ok := c.globalsTable[name]; ok {
-		return fmt.Errorf("can not redeclare const '%s'", name)
+		return Throw(ErrorClass, "can not redeclare const '%s'", name)
 	}
 	c.globalsTable[name] = value
 	return nil
@@ -81,7 +153,7 @@ func (c *context) GetGlobal(name string) (Object, error) {
 	if v, ok := c.globalsTable[name]; ok {
 		return v, nil
 	}
-	return nil, fmt.Errorf("name '%s' is not defined", name)
+	return nil, Throw(UndefinedNameErrorClass, "name '%s' is not defined", name)
 }
 
 func (c *context) GetContextVar(name string) (Object, error) {
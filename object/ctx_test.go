@@ -28,3 +28,32 @@ func TestLocalStorage_Get(t *testing.T) {
 		t.Errorf("expected value from parent context")
 	}
 }
+
+func TestLocalStorage_Capture(t *testing.T) {
+	outer := newLocalStorage()
+	outer.Set("value", &IntegerObject{Value: 1})
+	outer.Set("ref", &IntegerObject{Value: 2})
+
+	captured := newLocalStorage()
+	captured.Capture("value", outer, false)
+	captured.Capture("ref", outer, true)
+	captured.Capture("fresh", outer, true)
+
+	call := captured.Copy()
+	call.Set("value", &IntegerObject{Value: 10})
+	call.Set("ref", &IntegerObject{Value: 20})
+	call.Set("fresh", &IntegerObject{Value: 30})
+
+	if v, _ := outer.Get("value"); v.(*IntegerObject).Value != 1 {
+		t.Errorf("expected value captured by value to stay unchanged, got %d", v.(*IntegerObject).Value)
+	}
+	if v, _ := captured.Get("value"); v.(*IntegerObject).Value != 1 {
+		t.Errorf("expected captured value to be copied for a call, got %d", v.(*IntegerObject).Value)
+	}
+	if v, _ := outer.Get("ref"); v.(*IntegerObject).Value != 20 {
+		t.Errorf("expected value captured by reference to change, got %d", v.(*IntegerObject).Value)
+	}
+	if v, _ := outer.Get("fresh"); v.(*IntegerObject).Value != 30 {
+		t.Errorf("expected unset variable captured by reference to be declared, got %v", v)
+	}
+}
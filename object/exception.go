@@ -0,0 +1,188 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/pmukhin/gophp/ast"
+)
+
+var ThrowableInterface = NewInterface("Throwable", nil, nil)
+
+// exception classes are built as user classes so scripts can extend them
+var (
+	ExceptionClass   *UserClass
+	OsExceptionClass *UserClass
+
+	ErrorClass               *UserClass
+	TypeErrorClass           *UserClass
+	ArgumentCountErrorClass  *UserClass
+	ArithmeticErrorClass     *UserClass
+	DivisionByZeroErrorClass *UserClass
+	UndefinedNameErrorClass  *UserClass
+	IndexErrorClass          *UserClass
+	UnhandledMatchErrorClass *UserClass
+	AccessErrorClass         *UserClass
+)
+
+// classes are built in init as their methods refer to them
+func init() {
+	ExceptionClass = newThrowableClass("Exception", nil)
+	OsExceptionClass = newThrowableClass("os\\Exception", ExceptionClass)
+
+	ErrorClass = newThrowableClass("Error", nil)
+	TypeErrorClass = newThrowableClass("TypeError", ErrorClass)
+	ArgumentCountErrorClass = newThrowableClass("ArgumentCountError", TypeErrorClass)
+	ArithmeticErrorClass = newThrowableClass("ArithmeticError", ErrorClass)
+	DivisionByZeroErrorClass = newThrowableClass("DivisionByZeroError", ArithmeticErrorClass)
+	UndefinedNameErrorClass = newThrowableClass("UndefinedNameError", ErrorClass)
+	IndexErrorClass = newThrowableClass("IndexError", ErrorClass)
+	UnhandledMatchErrorClass = newThrowableClass("UnhandledMatchError", ErrorClass)
+	AccessErrorClass = newThrowableClass("AccessError", ErrorClass)
+}
+
+// newThrowableClass makes a class extending parent, root classes get
+// the methods of Throwable and implement it
+func newThrowableClass(name string, parent *UserClass) *UserClass {
+	if parent != nil {
+		return NewUserClass(name, parent, nil, false, false, map[string]Method{}, map[string]Object{})
+	}
+	methods := map[string]Method{
+		"__construct": newMethodWithArgs(exceptionConstruct, VisibilityPublic, exceptionConstructArgs...),
+		"getMessage":  newMethod(exceptionProperty("message"), VisibilityPublic),
+		"getCode":     newMethod(exceptionProperty("code"), VisibilityPublic),
+		"getPrevious": newMethod(exceptionProperty("previous"), VisibilityPublic),
+		"getTrace":    newMethod(exceptionProperty("trace"), VisibilityPublic),
+		"__toString":  newMethod(exceptionToString, VisibilityPublic),
+	}
+	properties := map[string]Object{
+		"message":  &StringObject{Value: ""},
+		"code":     &IntegerObject{Value: 0},
+		"previous": Null,
+		"trace":    &ArrayObject{},
+	}
+	class := NewUserClass(name, nil, []Class{ThrowableInterface}, false, false, methods, properties)
+	class.DeclarePropertyVisibility("message", VisibilityProtected)
+	class.DeclarePropertyVisibility("code", VisibilityProtected)
+	class.DeclarePropertyVisibility("previous", VisibilityPrivate)
+	class.DeclarePropertyVisibility("trace", VisibilityPrivate)
+
+	return class
+}
+
+func registerExceptionClasses(ctx Context) {
+	ctx.SetGlobal(ThrowableInterface.Name(), ThrowableInterface)
+	for _, class := range []*UserClass{
+		ExceptionClass, OsExceptionClass, ErrorClass, TypeErrorClass, ArgumentCountErrorClass,
+		ArithmeticErrorClass, DivisionByZeroErrorClass, UndefinedNameErrorClass, IndexErrorClass,
+		UnhandledMatchErrorClass, AccessErrorClass,
+	} {
+		ctx.SetGlobal(class.Name(), class)
+	}
+}
+
+// exceptionConstruct implements __construct($message = "", $code = 0, Throwable $previous = null)
+// exceptionConstructArgs are the parameters of Throwable::__construct
+var exceptionConstructArgs = []*ast.Arg{
+	{Type: &ast.Identifier{Value: "String"}, Name: ast.VariableExpression{Name: "message"}, DefaultValue: &ast.StringLiteral{Value: ""}},
+	{Type: &ast.Identifier{Value: "Int"}, Name: ast.VariableExpression{Name: "code"}, DefaultValue: &ast.IntegerLiteral{Value: 0}},
+	{Name: ast.VariableExpression{Name: "previous"}, DefaultValue: &ast.Identifier{Value: "null"}},
+}
+
+func exceptionConstruct(this Object, args ...Object) (Object, error) {
+	if len(args) > 3 {
+		return Null, Throw(ArgumentCountErrorClass, "%s::__construct expects at most 3 arguments, %d given",
+			this.Class().Name(), len(args))
+	}
+	e := this.(*UserObject)
+	if len(args) > 0 {
+		message, err := ToString(args[0])
+		if err != nil {
+			return Null, err
+		}
+		e.SetProperty("message", message)
+	}
+	if len(args) > 1 {
+		code, err := ToInteger(args[1])
+		if err != nil {
+			return Null, err
+		}
+		e.SetProperty("code", code)
+	}
+	if len(args) > 2 && args[2] != Null {
+		if !IsThrowable(args[2]) {
+			return Null, Throw(TypeErrorClass, "previous exception must be Throwable, %s given", args[2].Class().Name())
+		}
+		e.SetProperty("previous", args[2])
+	}
+	return Null, nil
+}
+
+func exceptionProperty(name string) func(this Object, args ...Object) (Object, error) {
+	return func(this Object, args ...Object) (Object, error) {
+		value, _ := this.(*UserObject).Property(name)
+		return value, nil
+	}
+}
+
+func exceptionToString(this Object, args ...Object) (Object, error) {
+	return &StringObject{Value: describeException(this.(*UserObject))}, nil
+}
+
+// describeException returns class name of the exception followed by its message
+func describeException(e *UserObject) string {
+	description := e.Class().Name()
+	if message, ok := e.Property("message"); ok {
+		if s, ok := message.(*StringObject); ok && s.Value != "" {
+			description += ": " + s.Value
+		}
+	}
+	return description
+}
+
+// IsThrowable reports whether o is an exception which can be thrown
+func IsThrowable(o Object) bool {
+	_, ok := o.(*UserObject)
+	return ok && IsInstanceOf(o.Class(), ThrowableInterface)
+}
+
+// NewException makes an instance of exception class with message
+func NewException(class *UserClass, message string) *UserObject {
+	e := NewUserObject(class)
+	e.SetProperty("message", &StringObject{Value: message})
+	return e
+}
+
+// SetTrace sets the call stack of an exception unless it's already set,
+// frames go from the innermost call
+func SetTrace(e *UserObject, frames []string) {
+	if trace, ok := e.Property("trace"); ok && len(trace.(*ArrayObject).Values) != 0 {
+		return
+	}
+	values := make([]Object, len(frames))
+	for i, frame := range frames {
+		values[i] = &StringObject{Value: frame}
+	}
+	e.SetProperty("trace", &ArrayObject{Values: values})
+}
+
+// ThrowError carries a thrown exception up to the try expression catching it
+type ThrowError struct {
+	Exception *UserObject
+}
+
+func (e *ThrowError) Error() string {
+	return "uncaught " + describeException(e.Exception)
+}
+
+// Throw makes an error throwing a new exception of class
+func Throw(class *UserClass, format string, args ...interface{}) error {
+	return &ThrowError{Exception: NewException(class, fmt.Sprintf(format, args...))}
+}
+
+// AsThrowError returns err if it's a ThrowError, other errors are turned into Error exceptions
+func AsThrowError(err error) *ThrowError {
+	if e, ok := err.(*ThrowError); ok {
+		return e
+	}
+	return &ThrowError{Exception: NewException(ErrorClass, err.Error())}
+}
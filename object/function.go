@@ -8,13 +8,12 @@ import (
 
 func funToString(this Object, args ...Object) (Object, error) {
 	if len(args) != 0 {
-		return Null, fmt.Errorf("expected 0 arguments, %d given", len(args))
+		return Null, Throw(ArgumentCountErrorClass, "expected 0 arguments, %d given", len(args))
 	}
 	f := this.(FunctionObject)
 	argsString := make([]string, len(f.Args()))
 	for i, arg := range f.Args() {
-		str := fmt.Sprintf(arg.String())
-		argsString[i] = str
+		argsString[i] = arg.String()
 	}
 	representation := fmt.Sprintf("<object of type %s, (%s): [NOT IMPLEMENTED]>", this.Class().Name(),
 		strings.Join(argsString, ", "))
@@ -35,6 +34,10 @@ var (
 	}
 )
 
+func registerFunctionConstants(ctx Context) {
+	ctx.SetGlobal(functionClass.name, functionClass)
+}
+
 func NewInternalFunc(f func(args ...Object) (Object, error)) FunctionObject {
 	return &InternalFunction{f: f}
 }
@@ -42,6 +45,7 @@ func NewInternalFunc(f func(args ...Object) (Object, error)) FunctionObject {
 type FunctionObject interface {
 	Object
 	Args() []*ast.Arg
+	ReturnType() *ast.Identifier
 	Block() *ast.BlockStatement
 }
 
@@ -60,6 +64,8 @@ func (inf InternalFunction) Args() []*ast.Arg {
 	return inf.args
 }
 
+func (InternalFunction) ReturnType() *ast.Identifier { return nil }
+
 func (InternalFunction) Block() *ast.BlockStatement { return nil }
 
 func (inf InternalFunction) Call(args ...Object) (Object, error) {
@@ -67,26 +73,35 @@ func (inf InternalFunction) Call(args ...Object) (Object, error) {
 }
 
 type UserFunction struct {
-	args  []*ast.Arg
-	block *ast.BlockStatement
+	args       []*ast.Arg
+	returnType *ast.Identifier
+	block      *ast.BlockStatement
+
+	// variables captured by a closure, nil for named functions
+	scope *localStorage
+	// class and called class of the method a closure is declared in
+	class       Class
+	calledClass Class
 }
 
-// NewAnonymousFunc ...
-func NewAnonymousFunc(args []*ast.Arg, block *ast.BlockStatement) FunctionObject {
-	b := make([]byte, 8)
-	for i := 0; i < 8; i++ {
-		b[i] = byte(i<<2*31 + i)
-	}
+// NewAnonymousFunc makes a closure with captured variables of scope, class and calledClass
+// are the ones of the method it's declared in
+func NewAnonymousFunc(args []*ast.Arg, returnType *ast.Identifier, block *ast.BlockStatement, scope *localStorage, class, calledClass Class) FunctionObject {
 	return &UserFunction{
-		args:  args,
-		block: block,
+		args:        args,
+		returnType:  returnType,
+		block:       block,
+		scope:       scope,
+		class:       class,
+		calledClass: calledClass,
 	}
 }
 
-func NewUserFunc(args []*ast.Arg, block *ast.BlockStatement) FunctionObject {
+func NewUserFunc(args []*ast.Arg, returnType *ast.Identifier, block *ast.BlockStatement) FunctionObject {
 	return &UserFunction{
-		args:  args,
-		block: block,
+		args:       args,
+		returnType: returnType,
+		block:      block,
 	}
 }
 
@@ -96,4 +111,16 @@ func (UserFunction) Id() string { panic("implement me") }
 
 func (uf UserFunction) Args() []*ast.Arg { return uf.args }
 
+func (uf UserFunction) ReturnType() *ast.Identifier { return uf.returnType }
+
 func (uf UserFunction) Block() *ast.BlockStatement { return uf.block }
+
+// Scope returns a copy of captured variables for a call of the function,
+// it's nil for functions capturing nothing
+func (uf UserFunction) Scope() *localStorage { return uf.scope.Copy() }
+
+// ScopeClass returns the class of the method a closure is declared in
+func (uf UserFunction) ScopeClass() Class { return uf.class }
+
+// CalledClass returns the class `static::` refers to inside of a closure
+func (uf UserFunction) CalledClass() Class { return uf.calledClass }
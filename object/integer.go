@@ -4,7 +4,7 @@ import "fmt"
 
 func infer(this Object, args ...Object) (*IntegerObject, *IntegerObject, error) {
 	if len(args) != 1 {
-		return nil, nil, fmt.Errorf("__add takes exactly one parameter, %d given", len(args))
+		return nil, nil, Throw(ArgumentCountErrorClass, "operator takes exactly one parameter, %d given", len(args))
 	}
 	i, ok := this.(*IntegerObject)
 	if !ok {
@@ -14,80 +14,128 @@ func infer(this Object, args ...Object) (*IntegerObject, *IntegerObject, error)
 	return i, r, e
 }
 
-func iEqual(this Object, os ...Object) (Object, error) {
+func iAdd(this Object, os ...Object) (Object, error) {
+	l, r, e := infer(this, os...)
+	if e != nil {
+		return Null, e
+	}
+	return &IntegerObject{Value: l.Value + r.Value}, nil
+}
+
+func iMod(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	if l.Value == r.Value {
-		return True, nil
+	if r.Value == 0 {
+		return Null, Throw(DivisionByZeroErrorClass, "modulo by zero is forbidden")
 	}
-	return False, nil
+	return &IntegerObject{Value: l.Value % r.Value}, nil
 }
 
-func isGreater(this Object, os ...Object) (Object, error) {
+func iSub(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	if l.Value > r.Value {
-		return True, nil
+	return &IntegerObject{Value: l.Value - r.Value}, nil
+}
+
+func iMul(this Object, os ...Object) (Object, error) {
+	l, r, e := infer(this, os...)
+	if e != nil {
+		return Null, e
 	}
-	return False, nil
+	return &IntegerObject{Value: l.Value * r.Value}, nil
 }
 
-func isLess(this Object, os ...Object) (Object, error) {
+func iDiv(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	if l.Value < r.Value {
-		return True, nil
+	if r.Value == 0 {
+		return Null, Throw(DivisionByZeroErrorClass, "division by zero is forbidden")
 	}
-	return False, nil
+	return &IntegerObject{Value: l.Value / r.Value}, nil
 }
 
-func iAdd(this Object, os ...Object) (Object, error) {
+func iPow(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	return &IntegerObject{Value: l.Value + r.Value}, nil
+	if r.Value < 0 {
+		return Null, Throw(ArithmeticErrorClass, "negative exponent %d is not supported for Int", r.Value)
+	}
+	result, base := int64(1), l.Value
+	for exp := r.Value; exp > 0; exp >>= 1 {
+		if exp&1 == 1 {
+			result *= base
+		}
+		base *= base
+	}
+	return &IntegerObject{Value: result}, nil
 }
 
-func iMod(this Object, os ...Object) (Object, error) {
+func iAnd(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	return &IntegerObject{Value: l.Value % r.Value}, nil
+	return &IntegerObject{Value: l.Value & r.Value}, nil
 }
 
-func iSub(this Object, os ...Object) (Object, error) {
+func iOr(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	return &IntegerObject{Value: l.Value - r.Value}, nil
+	return &IntegerObject{Value: l.Value | r.Value}, nil
 }
 
-func iMul(this Object, os ...Object) (Object, error) {
+func iXor(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	return &IntegerObject{Value: l.Value * r.Value}, nil
+	return &IntegerObject{Value: l.Value ^ r.Value}, nil
 }
 
-func iDiv(this Object, os ...Object) (Object, error) {
+func iShl(this Object, os ...Object) (Object, error) {
 	l, r, e := infer(this, os...)
 	if e != nil {
 		return Null, e
 	}
-	if r.Value == 0 {
-		return Null, fmt.Errorf("division by zero is forbidden")
+	if r.Value < 0 {
+		return Null, Throw(ArithmeticErrorClass, "bit shift by negative number %d", r.Value)
 	}
-	return &IntegerObject{Value: l.Value / r.Value}, nil
+	return &IntegerObject{Value: l.Value << uint64(r.Value)}, nil
+}
+
+func iShr(this Object, os ...Object) (Object, error) {
+	l, r, e := infer(this, os...)
+	if e != nil {
+		return Null, e
+	}
+	if r.Value < 0 {
+		return Null, Throw(ArithmeticErrorClass, "bit shift by negative number %d", r.Value)
+	}
+	return &IntegerObject{Value: l.Value >> uint64(r.Value)}, nil
+}
+
+func iNeg(this Object, args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return Null, Throw(ArgumentCountErrorClass, "__neg takes no parameters, %d given", len(args))
+	}
+	return &IntegerObject{Value: -this.(*IntegerObject).Value}, nil
+}
+
+func iBitNot(this Object, args ...Object) (Object, error) {
+	if len(args) != 0 {
+		return Null, Throw(ArgumentCountErrorClass, "__bitnot takes no parameters, %d given", len(args))
+	}
+	return &IntegerObject{Value: ^this.(*IntegerObject).Value}, nil
 }
 
 func iToBoolean(this Object, os ...Object) (Object, error) {
@@ -106,7 +154,7 @@ var (
 	ic = func(value interface{}) (Object, error) {
 		v, ok := value.(int64)
 		if !ok {
-			return nil, fmt.Errorf("%v is not an integer", value)
+			return nil, Throw(TypeErrorClass, "%v is not an integer", value)
 		}
 		return &IntegerObject{Value: v}, nil
 	}
@@ -116,10 +164,16 @@ var (
 		"__sub":       newMethod(iSub, VisibilityPublic),
 		"__mul":       newMethod(iMul, VisibilityPublic),
 		"__div":       newMethod(iDiv, VisibilityPublic),
-		"__equal":     newMethod(iEqual, VisibilityPublic),
-		"__gt":        newMethod(isGreater, VisibilityPublic),
-		"__lt":        newMethod(isLess, VisibilityPublic),
+		"__compare":   newMethod(compare, VisibilityPublic),
 		"__mod":       newMethod(iMod, VisibilityPublic),
+		"__pow":       newMethod(iPow, VisibilityPublic),
+		"__neg":       newMethod(iNeg, VisibilityPublic),
+		"__bitnot":    newMethod(iBitNot, VisibilityPublic),
+		"__and":       newMethod(iAnd, VisibilityPublic),
+		"__or":        newMethod(iOr, VisibilityPublic),
+		"__xor":       newMethod(iXor, VisibilityPublic),
+		"__shl":       newMethod(iShl, VisibilityPublic),
+		"__shr":       newMethod(iShr, VisibilityPublic),
 		"__toString":  newMethod(iToString, VisibilityPublic),
 		"__toBoolean": newMethod(iToBoolean, VisibilityPublic),
 	}
@@ -153,8 +207,11 @@ func (IntegerObject) Id() string {
 
 type integerConstructor struct{}
 
-func (integerConstructor) Call(this Object, object ...Object) (Object, error) {
-	panic("implement me")
+func (integerConstructor) Call(this Object, args ...Object) (Object, error) {
+	if len(args) != 1 {
+		return Null, Throw(ArgumentCountErrorClass, "Int constructor takes exactly one parameter, %d given", len(args))
+	}
+	return ToInteger(args[0])
 }
 
 func (integerConstructor) Visibility() Visibility {
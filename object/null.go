@@ -3,8 +3,12 @@ package object
 var (
 	nullMethods = map[string]Method{
 		"__toString": newMethod(func(this Object, args ...Object) (Object, error) {
-			return nil, nil
+			return &StringObject{Value: ""}, nil
 		}, VisibilityPublic),
+		"__toBoolean": newMethod(func(this Object, args ...Object) (Object, error) {
+			return False, nil
+		}, VisibilityPublic),
+		"__compare": newMethod(compare, VisibilityPublic),
 	}
 
 	classNull = &InternalClass{
@@ -21,3 +25,7 @@ type NullObject struct{}
 func (NullObject) Class() Class { return classNull }
 
 func (NullObject) Id() string { panic("id") }
+
+func registerNullConstants(ctx Context) {
+	ctx.SetGlobal(classNull.name, classNull)
+}
@@ -3,18 +3,17 @@ package object
 import (
 	"fmt"
 	"os"
-	"errors"
 )
 
 func doPrint(delimiter string) func(args ...Object) (Object, error) {
 	return func(args ...Object) (Object, error) {
 		if len(args) < 0 {
-			return Null, errors.New("println expects at least 1 argument")
+			return Null, Throw(ArgumentCountErrorClass, "println expects at least 1 argument")
 		}
 		for _, a := range args {
 			s, e := ToString(a)
 			if e != nil {
-				fmt.Printf("<%s> %v ", a.Class().Name(), s.Class())
+				fmt.Printf("<%s> ", a.Class().Name())
 				continue
 			}
 			fmt.Print(s.Value)
@@ -8,6 +8,10 @@ func RegisterGlobals(ctx Context) error {
 	registerIntConstants(ctx)
 	registerStringConstants(ctx)
 	registerArrayConstants(ctx)
+	registerBooleanConstants(ctx)
+	registerNullConstants(ctx)
+	registerFunctionConstants(ctx)
+	registerExceptionClasses(ctx)
 
 	return nil
 }
@@ -3,13 +3,12 @@ package object
 import (
 	"strings"
 	"strconv"
-	"errors"
 )
 
 func stringConcat(this Object, args ...Object) (Object, error) {
 	l := this.(*StringObject)
 	if len(args) != 1 {
-		panic("error")
+		return Null, Throw(ArgumentCountErrorClass, "__add takes exactly one parameter, %d given", len(args))
 	}
 	arg, e := ToString(args[0])
 	if e != nil {
@@ -21,7 +20,7 @@ func stringConcat(this Object, args ...Object) (Object, error) {
 func repeat(this Object, args ...Object) (Object, error) {
 	l := this.(*StringObject)
 	if len(args) != 1 {
-		panic("error")
+		return Null, Throw(ArgumentCountErrorClass, "__mul takes exactly one parameter, %d given", len(args))
 	}
 	arg, e := ToInteger(args[0])
 	if e != nil {
@@ -33,7 +32,7 @@ func repeat(this Object, args ...Object) (Object, error) {
 func toInt(this Object, args ...Object) (Object, error) {
 	l := this.(*StringObject)
 	if len(args) != 0 {
-		panic("error")
+		return Null, Throw(ArgumentCountErrorClass, "__toInt takes no parameters, %d given", len(args))
 	}
 	i, e := strconv.ParseInt(l.Value, 10, 64)
 	if e != nil {
@@ -45,7 +44,7 @@ func toInt(this Object, args ...Object) (Object, error) {
 func index(this Object, args ...Object) (Object, error) {
 	l := this.(*StringObject)
 	if len(args) != 1 {
-		panic("error")
+		return Null, Throw(ArgumentCountErrorClass, "__index takes exactly one parameter, %d given", len(args))
 	}
 	arg, e := ToInteger(args[0])
 	if e != nil {
@@ -53,18 +52,25 @@ func index(this Object, args ...Object) (Object, error) {
 	}
 	r := []rune(l.Value)
 	if len(r) <= int(arg.Value) {
-		return Null, errors.New("index error")
+		return Null, Throw(IndexErrorClass, "index %d is out of range", arg.Value)
 	}
 
 	return &StringObject{Value: string(r[arg.Value])}, nil
 }
 
+func stringToBoolean(this Object, args ...Object) (Object, error) {
+	l := this.(*StringObject)
+	return NativeBool(l.Value != "" && l.Value != "0"), nil
+}
+
 var (
 	m = map[string]Method{
-		"__add":   newMethod(stringConcat, VisibilityPublic),
-		"__mul":   newMethod(repeat, VisibilityPublic),
-		"__toInt": newMethod(toInt, VisibilityPublic),
-		"__index": newMethod(index, VisibilityPublic),
+		"__add":       newMethod(stringConcat, VisibilityPublic),
+		"__mul":       newMethod(repeat, VisibilityPublic),
+		"__toInt":     newMethod(toInt, VisibilityPublic),
+		"__index":     newMethod(index, VisibilityPublic),
+		"__compare":   newMethod(compare, VisibilityPublic),
+		"__toBoolean": newMethod(stringToBoolean, VisibilityPublic),
 	}
 
 	stringClass = &InternalClass{
@@ -1,14 +1,12 @@
 package object
 
-import "fmt"
-
 func ToString(o Object) (*StringObject, error) {
 	if o.Class().Name() == "String" {
 		return o.(*StringObject), nil
 	}
 	toString := o.Class().Methods().Find("__toString")
 	if toString == nil {
-		return nil, fmt.Errorf("%v can not be converted to String", o)
+		return nil, Throw(TypeErrorClass, "%s can not be converted to String", o.Class().Name())
 	}
 	argStr, e := toString.Call(o)
 	if e != nil {
@@ -23,7 +21,7 @@ func ToInteger(o Object) (*IntegerObject, error) {
 	}
 	toInt := o.Class().Methods().Find("__toInt")
 	if toInt == nil {
-		return nil, fmt.Errorf("%v can not be converted to Int", o)
+		return nil, Throw(TypeErrorClass, "%s can not be converted to Int", o.Class().Name())
 	}
 	argStr, e := toInt.Call(o)
 	if e != nil {
@@ -31,3 +29,41 @@ func ToInteger(o Object) (*IntegerObject, error) {
 	}
 	return argStr.(*IntegerObject), nil
 }
+
+// ToBoolean converts o to native bool with its `__toBoolean` method
+func ToBoolean(o Object) (bool, error) {
+	if b, ok := o.(*BooleanObject); ok {
+		return b.Value, nil
+	}
+	toBoolean := o.Class().Methods().Find("__toBoolean")
+	if toBoolean == nil {
+		return false, Throw(TypeErrorClass, "can not convert %s to Boolean", o.Class().Name())
+	}
+	result, e := toBoolean.Call(o)
+	if e != nil {
+		return false, e
+	}
+	b, ok := result.(*BooleanObject)
+	if !ok {
+		return false, Throw(TypeErrorClass, "%s::__toBoolean must return Boolean, %s returned", o.Class().Name(), result.Class().Name())
+	}
+	return b.Value, nil
+}
+
+// Compare returns -1, 0 or 1 when l is less than, equal to or greater than r
+// according to the `__compare` method of l
+func Compare(l, r Object) (int64, error) {
+	compare := l.Class().Methods().Find("__compare")
+	if compare == nil {
+		return 0, Throw(TypeErrorClass, "can not compare %s with %s", l.Class().Name(), r.Class().Name())
+	}
+	result, e := compare.Call(l, r)
+	if e != nil {
+		return 0, e
+	}
+	i, ok := result.(*IntegerObject)
+	if !ok {
+		return 0, Throw(TypeErrorClass, "%s::__compare must return Int, %s returned", l.Class().Name(), result.Class().Name())
+	}
+	return compareInts(i.Value, 0), nil
+}
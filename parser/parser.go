@@ -8,16 +8,30 @@ import (
 	"strings"
 	"strconv"
 	phperror "github.com/pmukhin/gophp/error"
-	"reflect"
 )
 
 const (
 	pLowest     = iota
+	pLogicalOr   // $a or $b
+	pLogicalXor  // $a xor $b
+	pLogicalAnd  // $a and $b
 	pBraces      // {
 	pAssignment  // $y = 0
+	pCoalesce    // $a ?? $b
+	pBooleanOr   // $a || $b
+	pBooleanAnd  // $a && $b
+	pBitwiseOr   // $a | $b
+	pBitwiseXor  // $a ^ $b
+	pBitwiseAnd  // $a & $b
+	pEquality    // $a == $b or $a <=> $b
+	pRelational  // $a < $b
+	pConcat      // $a . $b
+	pShift       // $a << $b
 	pSum         // + or -
 	pProduct     // *, /, %
+	pPow         // $a ** $b
 	pPrefix      // -$x or --$x
+	pCall        // f() or $a[0]
 )
 
 var accessModifiers = []int32{ast.ModPublic, ast.ModProtected, ast.ModPrivate}
@@ -25,19 +39,43 @@ var accessModifiers = []int32{ast.ModPublic, ast.ModProtected, ast.ModPrivate}
 var precedences = map[token.TokenType]int{
 	token.CURLY_OPEN: pBraces,
 
-	token.EQUAL: pAssignment,
+	token.EQUAL:          pAssignment,
+	token.PLUS_EQUAL:     pAssignment,
+	token.MINUS_EQUAL:    pAssignment,
+	token.MUL_EQUAL:      pAssignment,
+	token.DIV_EQUAL:      pAssignment,
+	token.MOD_EQUAL:      pAssignment,
+	token.POW_EQUAL:      pAssignment,
+	token.CONCAT_EQUAL:   pAssignment,
+	token.COALESCE_EQUAL: pAssignment,
+	token.AND_EQUAL:      pAssignment,
+	token.OR_EQUAL:       pAssignment,
+	token.XOR_EQUAL:      pAssignment,
+	token.SL_EQUAL:       pAssignment,
+	token.SR_EQUAL:       pAssignment,
 
 	token.DOUBLE_DOT: pAssignment,
 
-	token.IS_EQUAL:            1,
-	token.IS_IDENTICAL:        1,
-	token.IS_SMALLER:          1,
-	token.IS_SMALLER_OR_EQUAL: 1,
-	token.IS_GREATER:          1,
-	token.IS_GREATER_OR_EQUAL: 1,
-
-
-	token.PARENTHESIS_OPENING: 10,
+	token.LOGICAL_OR:  pLogicalOr,
+	token.LOGICAL_XOR: pLogicalXor,
+	token.LOGICAL_AND: pLogicalAnd,
+	token.COALESCE:    pCoalesce,
+	token.BOOLEAN_OR:  pBooleanOr,
+	token.BOOLEAN_AND: pBooleanAnd,
+
+	token.IS_EQUAL:            pEquality,
+	token.IS_NOT_EQUAL:        pEquality,
+	token.IS_IDENTICAL:        pEquality,
+	token.IS_NOT_IDENTICAL:    pEquality,
+	token.SPACESHIP:           pEquality,
+	token.IS_SMALLER:          pRelational,
+	token.IS_SMALLER_OR_EQUAL: pRelational,
+	token.IS_GREATER:          pRelational,
+	token.IS_GREATER_OR_EQUAL: pRelational,
+
+
+	token.PARENTHESIS_OPENING:    pCall,
+	token.SQUARE_BRACKET_OPENING: pCall,
 	//
 	token.PLUS:  pSum,
 	token.MINUS: pSum,
@@ -45,8 +83,19 @@ var precedences = map[token.TokenType]int{
 	token.MOD: pProduct,
 	token.DIV: pProduct,
 	token.MUL: pProduct,
+	token.POW: pPow,
 
-	token.OBJECT_OPERATOR: 5,
+	token.AMPERSAND:   pBitwiseAnd,
+	token.BITWISE_OR:  pBitwiseOr,
+	token.BITWISE_XOR: pBitwiseXor,
+	token.SL:          pShift,
+	token.SR:          pShift,
+	token.CONCAT:      pConcat,
+
+	token.INSTANCEOF:           pPrefix,
+	token.OBJECT_OPERATOR:          pCall,
+	token.NULLSAFE_OBJECT_OPERATOR: pCall,
+	token.PAAMAYIM_NEKUDOTAYIM: pCall,
 
 	token.INC: pPrefix,
 	token.DEC: pPrefix,
@@ -68,6 +117,11 @@ type Parser struct {
 
 	err error
 
+	// number of loops enclosing current expression inside current function
+	loopDepth int
+	// set while the body of a static method is parsed, there is no `$this` there
+	inStaticMethod bool
+
 	scn *scanner.Scanner
 }
 
@@ -115,6 +169,7 @@ func (p *Parser) init() {
 	p.prefixExpressionParsers[token.CONST] = p.parseConstant
 	p.prefixExpressionParsers[token.FUNCTION] = p.parseFunctionDeclaration
 	p.prefixExpressionParsers[token.CLASS] = p.parseClassDeclaration
+	p.prefixExpressionParsers[token.INTERFACE] = p.parseInterfaceDeclaration
 	p.prefixExpressionParsers[token.TRAIT] = p.parseTraitDeclaration
 	p.prefixExpressionParsers[token.SQUARE_BRACKET_OPENING] = p.parseArrayInitialization
 	p.prefixExpressionParsers[token.STRING] = p.parseStringLiteral
@@ -123,16 +178,48 @@ func (p *Parser) init() {
 	p.prefixExpressionParsers[token.IDENT] = p.parseIdentifier
 	p.prefixExpressionParsers[token.NUMBER] = p.parseInteger
 	p.prefixExpressionParsers[token.FOREACH] = p.parseForeach
-	p.prefixExpressionParsers[token.PUBLIC] = p.parseMethodDeclaration
+	p.prefixExpressionParsers[token.WHILE] = p.parseWhile
+	p.prefixExpressionParsers[token.FOR] = p.parseFor
+	p.prefixExpressionParsers[token.DO] = p.parseDoWhile
+	p.prefixExpressionParsers[token.BREAK] = p.parseLoopControl
+	p.prefixExpressionParsers[token.CONTINUE] = p.parseLoopControl
 	p.prefixExpressionParsers[token.NEW] = p.parseNewExpression
-
-	// class modifiers
+	p.prefixExpressionParsers[token.TRY] = p.parseTryExpression
+	p.prefixExpressionParsers[token.THROW] = p.parseThrowExpression
+	p.prefixExpressionParsers[token.MATCH] = p.parseMatchExpression
+	p.prefixExpressionParsers[token.SWITCH] = p.parseSwitchExpression
+	p.prefixExpressionParsers[token.ELLIPSIS] = p.parseSpreadExpression
+	p.prefixExpressionParsers[token.INC] = p.parseIncDec
+	p.prefixExpressionParsers[token.MINUS] = p.parseUnaryExpression
+	p.prefixExpressionParsers[token.PLUS] = p.parseUnaryExpression
+	p.prefixExpressionParsers[token.NOT] = p.parseUnaryExpression
+	p.prefixExpressionParsers[token.BITWISE_NOT] = p.parseUnaryExpression
+	p.prefixExpressionParsers[token.DEC] = p.parseIncDec
+
+	// class and member modifiers
 	p.prefixExpressionParsers[token.ABSTRACT] = p.parseModifiedExpression
 	p.prefixExpressionParsers[token.FINAL] = p.parseModifiedExpression
+	p.prefixExpressionParsers[token.PUBLIC] = p.parseModifiedExpression
+	p.prefixExpressionParsers[token.PROTECTED] = p.parseModifiedExpression
+	p.prefixExpressionParsers[token.PRIVATE] = p.parseModifiedExpression
+	p.prefixExpressionParsers[token.STATIC] = p.parseStatic
 
 	p.infixExpressionParsers = make(map[token.TokenType]infixParser)
 	// infix parsers
 	p.infixExpressionParsers[token.EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.PLUS_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.MINUS_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.MUL_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.DIV_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.MOD_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.POW_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.CONCAT_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.COALESCE_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.AND_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.OR_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.XOR_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.SL_EQUAL] = p.parseAssignment
+	p.infixExpressionParsers[token.SR_EQUAL] = p.parseAssignment
 	p.infixExpressionParsers[token.DOUBLE_DOT] = p.parseRangeExpression
 
 	p.infixExpressionParsers[token.PLUS] = p.parseBinaryExpression
@@ -140,21 +227,41 @@ func (p *Parser) init() {
 	p.infixExpressionParsers[token.MUL] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.DIV] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.MOD] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.POW] = p.parseRightAssociativeExpression
+
+	p.infixExpressionParsers[token.AMPERSAND] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.BITWISE_OR] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.BITWISE_XOR] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.SL] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.SR] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.CONCAT] = p.parseBinaryExpression
 
 	p.infixExpressionParsers[token.IS_GREATER] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.IS_GREATER_OR_EQUAL] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.IS_SMALLER] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.IS_SMALLER_OR_EQUAL] = p.parseBinaryExpression
 
+	p.infixExpressionParsers[token.COALESCE] = p.parseRightAssociativeExpression
+	p.infixExpressionParsers[token.BOOLEAN_AND] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.BOOLEAN_OR] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.LOGICAL_AND] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.LOGICAL_OR] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.LOGICAL_XOR] = p.parseBinaryExpression
+
 	p.infixExpressionParsers[token.IS_EQUAL] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.IS_NOT_EQUAL] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.IS_IDENTICAL] = p.parseBinaryExpression
 	p.infixExpressionParsers[token.IS_NOT_IDENTICAL] = p.parseBinaryExpression
+	p.infixExpressionParsers[token.SPACESHIP] = p.parseBinaryExpression
 
 	p.infixExpressionParsers[token.SQUARE_BRACKET_OPENING] = p.parseIndexExpression
 	p.infixExpressionParsers[token.INSTANCEOF] = p.parseInstanceOfExpression
 	p.infixExpressionParsers[token.OBJECT_OPERATOR] = p.parseFetchExpression
+	p.infixExpressionParsers[token.NULLSAFE_OBJECT_OPERATOR] = p.parseFetchExpression
+	p.infixExpressionParsers[token.PAAMAYIM_NEKUDOTAYIM] = p.parseStaticFetchExpression
 	p.infixExpressionParsers[token.PARENTHESIS_OPENING] = p.parseFunctionCall
+	p.infixExpressionParsers[token.INC] = p.parsePostfixIncDec
+	p.infixExpressionParsers[token.DEC] = p.parsePostfixIncDec
 
 	p.next()
 }
@@ -202,14 +309,95 @@ func (p *Parser) parseForeach() ast.Expression {
 	if endWithParen {
 		p.eatOfType(token.PARENTHESIS_CLOSING)
 	}
-	foreach.Block = p.parseBlock()
+	foreach.Block = p.parseLoopBody()
 
 	return foreach
 }
 
-// parseFor ... will there be for loop?
+// parseWhile parses `while $condition { ... }`, the condition may be put in parentheses
+func (p *Parser) parseWhile() ast.Expression {
+	we := &ast.WhileExpression{Token: p.curToken}
+	p.next() // eat `while`
+
+	we.Condition = p.parseExpression(pLowest)
+	if p.err != nil {
+		return nil
+	}
+	if we.Body = p.parseLoopBody(); we.Body == nil {
+		return nil
+	}
+
+	return we
+}
+
+// parseDoWhile parses `do { ... } while $condition`, `while` may start on a new line
+func (p *Parser) parseDoWhile() ast.Expression {
+	dwe := &ast.DoWhileExpression{Token: p.curToken}
+	p.next() // eat `do`
+
+	if dwe.Body = p.parseLoopBody(); dwe.Body == nil {
+		return nil
+	}
+	if !p.skipNewlineBefore(token.WHILE) {
+		p.emitError("expected while after do block, %s given", p.curToken.Literal)
+		return nil
+	}
+	p.next() // eat `while`
+	dwe.Condition = p.parseExpression(pLowest)
+
+	return dwe
+}
+
+// parseFor parses `for ($i = 0; $i < $n; $i = $i + 1) { ... }`, parentheses are optional,
+// init and step parts are comma separated lists and any part may be empty
 func (p *Parser) parseFor() ast.Expression {
-	panic("not implemented")
+	fe := &ast.ForExpression{Token: p.curToken}
+	p.next() // eat `for`
+
+	endWithParen := false
+	if p.oneOf(token.PARENTHESIS_OPENING) {
+		endWithParen = true
+		p.next() // eat `(`
+	}
+	fe.Init = p.parseForClause(token.SEMICOLON)
+	p.eatOfType(token.SEMICOLON)
+	if p.err != nil {
+		return nil
+	}
+	if !p.oneOf(token.SEMICOLON) {
+		fe.Condition = p.parseExpression(pLowest)
+	}
+	p.eatOfType(token.SEMICOLON)
+	if p.err != nil {
+		return nil
+	}
+	if endWithParen {
+		fe.Inc = p.parseForClause(token.PARENTHESIS_CLOSING)
+		p.eatOfType(token.PARENTHESIS_CLOSING)
+	} else {
+		fe.Inc = p.parseForClause(token.CURLY_OPENING)
+	}
+	if p.err != nil {
+		return nil
+	}
+	if fe.Body = p.parseLoopBody(); fe.Body == nil {
+		return nil
+	}
+
+	return fe
+}
+
+// parseForClause parses comma separated expressions of a for clause up to a token of type end
+func (p *Parser) parseForClause(end token.TokenType) []ast.Expression {
+	list := make([]ast.Expression, 0, 2)
+	for !p.oneOf(end) && p.err == nil {
+		list = append(list, p.parseExpression(pLowest))
+		if !p.oneOf(token.COMMA) {
+			break
+		}
+		p.next() // eat `,`
+	}
+	return list
 }
 
 func (p *Parser) parseStatement() ast.Statement {
@@ -320,6 +508,24 @@ func (p *Parser) parseUseStatement() *ast.UseStatement {
 
 // parseFunctionDeclaration
 func (p *Parser) parseFunctionDeclaration() ast.Expression {
+	fun := p.parseFunctionSignature()
+	if fun == nil {
+		return nil
+	}
+
+	if p.curToken.Type == token.CURLY_OPENING {
+		fun.Block = p.parseFunctionBody()
+	} else {
+		p.emitError("expected : or {, got %s", p.curToken.Literal)
+		return nil
+	}
+
+	return fun
+}
+
+// parseFunctionSignature parses everything but the body of
+// `function <Name> (<Args>): <ReturnType>`
+func (p *Parser) parseFunctionSignature() *ast.FunctionDeclarationExpression {
 	fun := &ast.FunctionDeclarationExpression{Token: p.curToken}
 	p.next() // eat `function`
 
@@ -336,19 +542,19 @@ func (p *Parser) parseFunctionDeclaration() ast.Expression {
 	fun.Args = p.parseArgs()
 
 	if p.curToken.Type == token.USE {
+		if !fun.Anonymous {
+			p.emitError("only anonymous functions can capture variables with use")
+			return nil
+		}
 		p.next() // eat `use`
-		//fun.BindVars = p.parse
+		fun.BindVars = p.parseBindVars()
 	}
 
 	// have a return type
 	if p.curToken.Type == token.COLON {
 		fun.ReturnType = p.parseReturnType()
 	}
-
-	if p.curToken.Type == token.CURLY_OPENING {
-		fun.Block = p.parseBlock()
-	} else {
-		p.emitError("expected : or {, got %s", p.curToken.Literal)
+	if p.err != nil {
 		return nil
 	}
 
@@ -388,16 +594,23 @@ func (p *Parser) parseArgs() []*ast.Arg {
 		// okay, we have an arg
 		var arg *ast.Arg
 		// we have a type!
-		if p.curToken.Type == token.IDENT {
+		if p.oneOf(token.IDENT, token.QUESTION_MARK) {
 			arg = p.parseTypedArg()
-		} /* no type, just var def */ else if p.curToken.Type == token.VAR {
+		} /* no type, just var def */ else if p.oneOf(token.VAR, token.ELLIPSIS) {
 			arg = p.parseArg()
 		} else {
 			p.emitError("unexpected token %s", p.curToken.Literal)
 			return nil
 		}
+		if p.err != nil {
+			return nil
+		}
 		args = append(args, arg)
 
+		if arg.Variadic && !p.oneOf(token.PARENTHESIS_CLOSING) {
+			p.emitErrorInPos(arg.Token.Pos, "variadic argument %s must be the last one", arg.Name.String())
+			return nil
+		}
 		if p.curToken.Type == token.PARENTHESIS_CLOSING {
 			break
 		} else if p.curToken.Type == token.COMMA {
@@ -415,13 +628,24 @@ func (p *Parser) parseArgs() []*ast.Arg {
 // function()`: ReturnTypeClass` {
 func (p *Parser) parseReturnType() *ast.Identifier {
 	p.next() // eat `:`
-	p.assertTokenType(token.IDENT)
-	returnType := p.curToken.Literal
-	p.next() // eat IDENT
+	returnType := p.parseTypeName()
 	// token.IDENT is return type
 	return &ast.Identifier{Token: p.curToken, Value: returnType}
 }
 
+// parseTypeName parses `Type` or nullable `?Type`, the latter keeps its `?`
+func (p *Parser) parseTypeName() string {
+	prefix := ""
+	if p.oneOf(token.QUESTION_MARK) {
+		prefix = "?"
+		p.next() // eat `?`
+	}
+	p.assertTokenType(token.IDENT)
+	typeName := prefix + p.curToken.Literal
+	p.next() // eat IDENT
+	return typeName
+}
+
 func (p *Parser) parseBlock() *ast.BlockStatement {
 	p.next() // eat `{`
 	block := new(ast.BlockStatement)
@@ -436,14 +660,41 @@ func (p *Parser) parseBlock() *ast.BlockStatement {
 	return block
 }
 
+// parseBindVars parses variables captured by a closure `($value, &$reference)`
+func (p *Parser) parseBindVars() []*ast.BindVar {
+	p.eatOfType(token.PARENTHESIS_OPENING)
+	vars := make([]*ast.BindVar, 0, 4)
+	for p.err == nil && !p.oneOf(token.PARENTHESIS_CLOSING) {
+		bv := &ast.BindVar{Token: p.curToken}
+		if p.oneOf(token.AMPERSAND) {
+			bv.IsReference = true
+			p.next() // eat `&`
+		}
+		p.assertTokenType(token.VAR)
+		if p.err != nil {
+			return nil
+		}
+		bv.Variable = p.parseVariable().(*ast.VariableExpression)
+		vars = append(vars, bv)
+		if !p.oneOf(token.COMMA) {
+			break
+		}
+		p.next() // eat `,`
+	}
+	p.eatOfType(token.PARENTHESIS_CLOSING)
+
+	return vars
+}
+
 // parseTypedArg parses typed arg like `array $values = []`
 func (p *Parser) parseTypedArg() *ast.Arg {
 	arg := new(ast.Arg)
-	typeName := p.curToken.Literal // eat `Type`
-	p.next()                       // eat ident
+	typeName := p.parseTypeName()
 	t := &ast.Identifier{Value: typeName, Token: p.curToken}
 
-	arg = p.parseArg()
+	if arg = p.parseArg(); arg == nil {
+		return nil
+	}
 	arg.Type = t
 
 	return arg
@@ -452,6 +703,11 @@ func (p *Parser) parseTypedArg() *ast.Arg {
 // parseArg parses untyped arg like `$value = "someDefaultString"`
 func (p *Parser) parseArg() *ast.Arg {
 	arg := &ast.Arg{Token: p.curToken}
+	if p.oneOf(token.ELLIPSIS) {
+		arg.Variadic = true
+		p.next() // eat `...`
+	}
+	p.assertTokenType(token.VAR)
 	p.next() // eat `$`
 	// should get $`string_` here
 	p.assertTokenType(token.IDENT)
@@ -459,9 +715,13 @@ func (p *Parser) parseArg() *ast.Arg {
 	arg.Name = ast.VariableExpression{Name: p.curToken.Literal, Token: p.curToken}
 	p.next() // eat name
 
-	if p.peek().Type == token.EQUAL {
+	if p.oneOf(token.EQUAL) {
+		if arg.Variadic {
+			p.emitError("variadic argument %s can not have a default value", arg.Name.String())
+			return nil
+		}
 		// we have assign
-		p.eatOfType(token.EQUAL)
+		p.next() // eat `=`
 		arg.DefaultValue = p.parseExpression(pLowest)
 	}
 	return arg
@@ -485,7 +745,8 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	// we got the left
 	// e.g. for variable assignment it's $
 	left := prefix()
-	for precedence < p.getPrecedence() {
+	// stop at the first error, the left side may be incomplete
+	for p.err == nil && precedence < p.getPrecedence() {
 		if p.curToken.Type == token.SEMICOLON {
 			return left
 		}
@@ -505,6 +766,10 @@ func (p *Parser) parseVariable() ast.Expression {
 	p.next() // eat $
 	p.assertTokenType(token.IDENT)
 	variable.Name = p.curToken.Literal
+	if p.inStaticMethod && variable.Name == "this" {
+		p.emitError("can not use $this in a static method")
+		return nil
+	}
 	// eat IDENT
 	p.next()
 	return variable
@@ -525,54 +790,346 @@ func (p *Parser) parseNewExpression() ast.Expression {
 	p.next() // eat `new`
 
 	cle.ClassName = p.parseIdentifier().(*ast.Identifier)
-	cle.Args = p.parseExpressionList()
+	// `new Foo` is the same as `new Foo()`
+	if p.oneOf(token.PARENTHESIS_OPENING) {
+		cle.Args = p.parseExpressionList()
+	}
 
 	return cle
 }
 
-func (p *Parser) parseMethodDeclaration() ast.Expression {
-	mde := &ast.MethodDeclarationExpression{Token: p.curToken}
-	mde.Access = modifier(p.curToken.Type)
-	p.next() // eat `public|protected|private`
+// parseMethodDeclaration parses the function part of
+// `public function name($args) { ... }`, modifiers are already eaten.
+// Abstract and interface methods have no body, so it's optional here
+func (p *Parser) parseMethodDeclaration(tok token.Token) ast.Expression {
+	mde := &ast.MethodDeclarationExpression{Token: tok, Access: ast.ModPublic}
 
-	fun := p.parseFunctionDeclaration()
-	mde.FunctionDeclarationExpression = *(fun.(*ast.FunctionDeclarationExpression))
+	fun := p.parseFunctionSignature()
+	if fun == nil {
+		return nil
+	}
+	if fun.Anonymous {
+		p.emitErrorInPos(fun.Pos(), "method must have a name")
+		return nil
+	}
+	if p.oneOf(token.CURLY_OPENING) {
+		fun.Block = p.parseFunctionBody()
+	}
+	mde.FunctionDeclarationExpression = *fun
 
 	return mde
 }
 
+// parsePropertyDeclaration parses the variable part of
+// `public $name = "default"`, modifiers are already eaten
+func (p *Parser) parsePropertyDeclaration(tok token.Token) ast.Expression {
+	pde := &ast.PropertyDeclarationExpression{Token: tok, Access: ast.ModPublic}
+	pde.Name = p.parseVariable().(*ast.VariableExpression)
+
+	if p.oneOf(token.EQUAL) {
+		p.next() // eat `=`
+		pde.DefaultValue = p.parseExpression(pLowest)
+	}
+
+	return pde
+}
+
 func (p *Parser) parseClassDeclaration() ast.Expression {
 	cde := &ast.ClassDeclarationExpression{Token: p.curToken}
 	p.next() // eat `class`
 
 	cde.Name = p.parseIdentifier().(*ast.Identifier)
-	cde.Block = p.parseBlock()
+	if p.oneOf(token.EXTENDS) {
+		p.next() // eat `extends`
+		p.assertTokenType(token.IDENT)
+		cde.Parent = p.parseIdentifier().(*ast.Identifier)
+	}
+	if p.oneOf(token.IMPLEMENTS) {
+		p.next() // eat `implements`
+		cde.Interfaces = p.parseIdentifierList()
+	}
+	cde.Block = p.parseClassBody()
+	if p.err != nil {
+		return nil
+	}
+
+	for _, member := range cde.Block.Statements {
+		mde, ok := member.(*ast.ExpressionStatement).Expression.(*ast.MethodDeclarationExpression)
+		if !ok {
+			continue
+		}
+		if mde.IsAbstract && mde.Block != nil {
+			p.emitErrorInPos(mde.Pos(), "abstract method %s::%s can not have a body", cde.Name.Value, mde.Name.Value)
+			return nil
+		}
+		if !mde.IsAbstract && mde.Block == nil {
+			p.emitErrorInPos(mde.Pos(), "non-abstract method %s::%s must have a body", cde.Name.Value, mde.Name.Value)
+			return nil
+		}
+	}
 
 	return cde
 }
 
+// parseInterfaceDeclaration parses
+// `interface Name extends First, Second { public function method($arg); }`
+func (p *Parser) parseInterfaceDeclaration() ast.Expression {
+	ide := &ast.InterfaceDeclarationExpression{Token: p.curToken}
+	p.next() // eat `interface`
+
+	p.assertTokenType(token.IDENT)
+	ide.Name = p.parseIdentifier().(*ast.Identifier)
+	if p.oneOf(token.EXTENDS) {
+		p.next() // eat `extends`
+		ide.Parents = p.parseIdentifierList()
+	}
+	ide.Block = p.parseClassBody()
+	if p.err != nil {
+		return nil
+	}
+
+	for _, member := range ide.Block.Statements {
+		mde, ok := member.(*ast.ExpressionStatement).Expression.(*ast.MethodDeclarationExpression)
+		if !ok {
+			p.emitErrorInPos(ide.Pos(), "interface %s may only contain methods, %s given", ide.Name.Value, member.String())
+			return nil
+		}
+		if mde.Block != nil {
+			p.emitErrorInPos(mde.Pos(), "interface method %s::%s can not have a body", ide.Name.Value, mde.Name.Value)
+			return nil
+		}
+	}
+
+	return ide
+}
+
+// parseIdentifierList parses comma separated names like `First, Second`
+func (p *Parser) parseIdentifierList() []*ast.Identifier {
+	list := make([]*ast.Identifier, 0, 4)
+	for {
+		p.assertTokenType(token.IDENT)
+		if p.err != nil {
+			return nil
+		}
+		list = append(list, p.parseIdentifier().(*ast.Identifier))
+		if !p.oneOf(token.COMMA) {
+			break
+		}
+		p.next() // eat `,`
+	}
+	return list
+}
+
+// parseClassBody parses members of a class, an interface or a trait
+func (p *Parser) parseClassBody() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	p.eatOfType(token.CURLY_OPENING)
+	if p.err != nil {
+		return nil
+	}
+
+	for !p.oneOf(token.CURLY_CLOSING) {
+		var member ast.Expression
+		switch p.curToken.Type {
+		case token.SEMICOLON, token.COMMENT:
+			p.next() // eat `;` or comment
+			continue
+		case token.EOF:
+			p.emitError("unexpected token EOF")
+			return nil
+		case token.FUNCTION:
+			member = p.parseMethodDeclaration(p.curToken)
+		case token.USE:
+			member = p.parseTraitUse()
+		default:
+			member = p.parseExpression(pLowest)
+		}
+		if member == nil || p.err != nil {
+			return nil
+		}
+		block.Statements = append(block.Statements, &ast.ExpressionStatement{Expression: member})
+	}
+	p.next() // eat `}`
+
+	return block
+}
+
+// parseTraitDeclaration parses
+// `trait Name { public function method() { ... } }`
 func (p *Parser) parseTraitDeclaration() ast.Expression {
-	panic("implement me")
+	tde := &ast.TraitDeclarationExpression{Token: p.curToken}
+	p.next() // eat `trait`
+
+	p.assertTokenType(token.IDENT)
+	tde.Name = p.parseIdentifier().(*ast.Identifier)
+	tde.Block = p.parseClassBody()
+	if p.err != nil {
+		return nil
+	}
+
+	for _, member := range tde.Block.Statements {
+		switch m := member.(*ast.ExpressionStatement).Expression.(type) {
+		case *ast.MethodDeclarationExpression:
+			if !m.IsAbstract && m.Block == nil {
+				p.emitErrorInPos(m.Pos(), "non-abstract method %s::%s must have a body", tde.Name.Value, m.Name.Value)
+				return nil
+			}
+		case *ast.PropertyDeclarationExpression:
+		default:
+			p.emitErrorInPos(tde.Pos(), "trait %s may only contain methods and properties, %s given", tde.Name.Value, m.String())
+			return nil
+		}
+	}
+
+	return tde
+}
+
+// parseTraitUse parses
+// `use First, Second { First::method insteadof Second; Second::method as protected alias; }`
+func (p *Parser) parseTraitUse() ast.Expression {
+	tue := &ast.TraitUseExpression{Token: p.curToken}
+	p.next() // eat `use`
+
+	tue.Traits = p.parseIdentifierList()
+	if p.err != nil || !p.oneOf(token.CURLY_OPENING) {
+		return tue
+	}
+	p.next() // eat `{`
+
+	for !p.oneOf(token.CURLY_CLOSING) {
+		if p.oneOf(token.SEMICOLON) {
+			p.next() // eat `;`
+			continue
+		}
+		rule := p.parseTraitRule()
+		if rule == nil {
+			return nil
+		}
+		tue.Rules = append(tue.Rules, rule)
+	}
+	p.next() // eat `}`
+
+	return tue
+}
+
+// parseTraitRule parses either `Trait::method insteadof Other` or `[Trait::]method as [modifier] [alias]`
+func (p *Parser) parseTraitRule() *ast.TraitRule {
+	rule := &ast.TraitRule{Token: p.curToken}
+
+	p.assertTokenType(token.IDENT)
+	if p.err != nil {
+		return nil
+	}
+	name := p.parseIdentifier().(*ast.Identifier)
+	if p.oneOf(token.PAAMAYIM_NEKUDOTAYIM) {
+		p.next() // eat `::`
+		p.assertTokenType(token.IDENT)
+		if p.err != nil {
+			return nil
+		}
+		rule.Trait = name
+		name = p.parseIdentifier().(*ast.Identifier)
+	}
+	rule.Method = name
+
+	switch p.curToken.Type {
+	case token.INSTEADOF:
+		if rule.Trait == nil {
+			p.emitError("expected Trait::%s before insteadof", name.Value)
+			return nil
+		}
+		p.next() // eat `insteadof`
+		rule.InsteadOf = p.parseIdentifierList()
+	case token.AS:
+		p.next() // eat `as`
+		if isModifier(p.curToken.Type) {
+			mod := modifier(p.curToken.Type)
+			if mod != ast.ModPublic && mod != ast.ModProtected && mod != ast.ModPrivate {
+				p.emitError("unexpected modifier %s in trait alias", p.curToken.Literal)
+				return nil
+			}
+			rule.Access = mod
+			rule.HasAccess = true
+			p.next() // eat modifier
+		}
+		if p.oneOf(token.IDENT) {
+			rule.Alias = p.parseIdentifier().(*ast.Identifier)
+		} else if !rule.HasAccess {
+			p.emitError("expected alias or visibility after as, got %s", p.curToken.Literal)
+			return nil
+		}
+	default:
+		p.emitError("expected insteadof or as, got %s", p.curToken.Literal)
+		return nil
+	}
+	if p.err != nil {
+		return nil
+	}
+
+	return rule
 }
 
 func (p *Parser) parseAssignment(left ast.Expression) ast.Expression {
 	as := &ast.AssignmentExpression{Token: p.curToken}
+	// `+=` is stored as operator `+`
+	as.Operator = strings.TrimSuffix(p.curToken.Literal, "=")
 	p.next() // eat `=`
 
-	switch left.(type) {
-	case *ast.VariableExpression:
-	case *ast.ConstantExpression:
-		// do noting, that's okay
-	default:
+	// constants are only declared, never updated
+	if _, ok := left.(*ast.ConstantExpression); !(ok && as.Operator == "") && !isAssignable(left) {
 		p.emitError("can not assign to %s", left.String())
 		return nil
 	}
 	as.Left = left
-	as.Right = p.parseExpression(pLowest)
+	// `$x = $a and $b` assigns $a, word operators bind looser than assignment
+	as.Right = p.parseExpression(pLogicalAnd)
 
 	return as
 }
 
+// parseUnaryExpression parses `-$x`, `+$x`, `!$x` and `~$x`
+func (p *Parser) parseUnaryExpression() ast.Expression {
+	ue := &ast.UnaryExpression{Token: p.curToken, Op: p.curToken.Literal, IsPrefix: true}
+	p.next() // eat operator
+
+	// `->` and `**` bind tighter so that `!$o->valid()` negates the result of the call
+	// and `-2 ** 2` is `-(2 ** 2)`
+	ue.Right = p.parseExpression(pProduct)
+	if p.err != nil {
+		return nil
+	}
+	return ue
+}
+
+// parseIncDec parses prefix `++$i` and `--$i`
+func (p *Parser) parseIncDec() ast.Expression {
+	ue := &ast.UnaryExpression{Token: p.curToken, Op: p.curToken.Literal, IsPrefix: true}
+	p.next() // eat `++`
+
+	// `->` binds tighter so that `++$o->count` increments the property
+	ue.Right = p.parseExpression(pPow)
+	if p.err != nil {
+		return nil
+	}
+	if !isAssignable(ue.Right) {
+		p.emitErrorInPos(ue.Token.Pos, "can not %s %s", incDecVerb(ue.Op), ue.Right.String())
+		return nil
+	}
+	return ue
+}
+
+// parsePostfixIncDec parses postfix `$i++` and `$i--`
+func (p *Parser) parsePostfixIncDec(left ast.Expression) ast.Expression {
+	ue := &ast.UnaryExpression{Token: p.curToken, Op: p.curToken.Literal, Right: left}
+	if !isAssignable(left) {
+		p.emitError("can not %s %s", incDecVerb(ue.Op), left.String())
+		return nil
+	}
+	p.next() // eat `++`
+
+	return ue
+}
+
 func (p *Parser) parseRangeExpression(left ast.Expression) ast.Expression {
 	re := &ast.RangeExpression{}
 	p.next() // eat `..`
@@ -598,6 +1155,18 @@ func (p *Parser) parseBinaryExpression(left ast.Expression) ast.Expression {
 	return be
 }
 
+// parseRightAssociativeExpression parses `??` and `**` which group to the right,
+// e.g. `2 ** 3 ** 2` is `2 ** (3 ** 2)` and `$a ?? $b ?? $c` is `$a ?? ($b ?? $c)`
+func (p *Parser) parseRightAssociativeExpression(left ast.Expression) ast.Expression {
+	be := &ast.BinaryExpression{Token: p.curToken, Op: p.curToken.Literal, Left: left}
+	precedence := precedences[p.curToken.Type]
+	p.next() // eat operator
+
+	be.Right = p.parseExpression(precedence - 1)
+
+	return be
+}
+
 func (p *Parser) parseArrayInitialization() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	p.next() // eat `[`
@@ -651,6 +1220,259 @@ func (p *Parser) parseConditionalExpression() ast.Expression {
 	return ce
 }
 
+// parseTryExpression parses `try { ... } catch (Exception $e) { ... } finally { ... }`,
+// catch and finally clauses may start on a new line
+func (p *Parser) parseTryExpression() ast.Expression {
+	te := &ast.TryExpression{Token: p.curToken}
+	p.next() // eat `try`
+
+	if te.Block = p.parseClauseBlock(); te.Block == nil {
+		return nil
+	}
+	for p.skipNewlineBefore(token.CATCH) {
+		catch := &ast.CatchClause{Token: p.curToken}
+		p.next() // eat `catch`
+		p.eatOfType(token.PARENTHESIS_OPENING)
+		p.assertTokenType(token.IDENT)
+		if p.err != nil {
+			return nil
+		}
+		catch.Type = p.parseIdentifier().(*ast.Identifier)
+		if p.oneOf(token.VAR) {
+			catch.Variable = p.parseVariable().(*ast.VariableExpression)
+		}
+		p.eatOfType(token.PARENTHESIS_CLOSING)
+		if catch.Block = p.parseClauseBlock(); catch.Block == nil {
+			return nil
+		}
+		te.Catches = append(te.Catches, catch)
+	}
+	if p.skipNewlineBefore(token.FINALLY) {
+		p.next() // eat `finally`
+		if te.Finally = p.parseClauseBlock(); te.Finally == nil {
+			return nil
+		}
+	}
+	if len(te.Catches) == 0 && te.Finally == nil {
+		p.emitErrorInPos(te.Token.Pos, "try must be followed by catch or finally")
+		return nil
+	}
+
+	return te
+}
+
+// parseLoopBody parses the block of a loop, break and continue are allowed inside it
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+
+	return p.parseClauseBlock()
+}
+
+// parseFunctionBody parses the block of a function, loops around the declaration
+// can not be left from inside of it
+func (p *Parser) parseFunctionBody() *ast.BlockStatement {
+	depth := p.loopDepth
+	p.loopDepth = 0
+	defer func() { p.loopDepth = depth }()
+
+	return p.parseBlock()
+}
+
+// parseLoopControl parses `break` and `continue` followed by optional number of loops to leave
+func (p *Parser) parseLoopControl() ast.Expression {
+	tok := p.curToken
+	p.next() // eat `break` or `continue`
+
+	levels := int64(1)
+	if p.oneOf(token.NUMBER) {
+		n, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
+		if err != nil || n < 1 {
+			p.emitError("%s level must be a positive integer, got %s", tok.Literal, p.curToken.Literal)
+			return nil
+		}
+		levels = n
+		p.next() // eat NUMBER
+	}
+	if p.loopDepth == 0 {
+		p.emitErrorInPos(tok.Pos, "%s is not in a loop or switch", tok.Literal)
+		return nil
+	}
+	if levels > int64(p.loopDepth) {
+		p.emitErrorInPos(tok.Pos, "can not %s %d levels, only %d enclosing loops or switches", tok.Literal, levels, p.loopDepth)
+		return nil
+	}
+	if tok.Type == token.BREAK {
+		return &ast.BreakExpression{Token: tok, Levels: levels}
+	}
+	return &ast.ContinueExpression{Token: tok, Levels: levels}
+}
+
+// parseClauseBlock parses a block which must follow a keyword like `try`
+func (p *Parser) parseClauseBlock() *ast.BlockStatement {
+	p.assertTokenType(token.CURLY_OPENING)
+	if p.err != nil {
+		return nil
+	}
+	return p.parseBlock()
+}
+
+// skipNewlineBefore skips a newline if it's followed by a token of type t,
+// it reports whether the current token is of type t
+func (p *Parser) skipNewlineBefore(t token.TokenType) bool {
+	if p.curToken.Type == token.SEMICOLON && p.peek().Type == t {
+		p.next() // eat `;`
+	}
+	return p.curToken.Type == t
+}
+
+// parseMatchExpression parses `match $subject { 1, 2 => $a, Int if $guard => $b, default => $c }`,
+// arms are separated by commas or new lines
+func (p *Parser) parseMatchExpression() ast.Expression {
+	me := &ast.MatchExpression{Token: p.curToken}
+	p.next() // eat `match`
+
+	me.Subject = p.parseExpression(pLowest)
+	p.eatOfType(token.CURLY_OPENING)
+	if p.err != nil {
+		return nil
+	}
+	hasDefault := false
+	for p.skipArmSeparators(); !p.oneOf(token.CURLY_CLOSING); p.skipArmSeparators() {
+		arm := p.parseMatchArm()
+		if arm == nil {
+			return nil
+		}
+		if arm.IsDefault() {
+			if hasDefault {
+				p.emitErrorInPos(arm.Token.Pos, "match can have only one default arm")
+				return nil
+			}
+			hasDefault = true
+		}
+		me.Arms = append(me.Arms, arm)
+	}
+	p.next() // eat `}`
+
+	return me
+}
+
+// parseMatchArm parses `$a, $b if $guard => $result` or `default => $result`
+func (p *Parser) parseMatchArm() *ast.MatchArm {
+	arm := &ast.MatchArm{Token: p.curToken}
+	if p.oneOf(token.DEFAULT) {
+		p.next() // eat `default`
+	} else {
+		for {
+			arm.Conditions = append(arm.Conditions, p.parseExpression(pLowest))
+			if p.err != nil {
+				return nil
+			}
+			if !p.oneOf(token.COMMA) {
+				break
+			}
+			p.next() // eat `,`
+		}
+		if p.oneOf(token.IF) {
+			p.next() // eat `if`
+			arm.Guard = p.parseExpression(pLowest)
+		}
+	}
+	p.eatOfType(token.DOUBLE_ARROW)
+	if p.err != nil {
+		return nil
+	}
+	arm.Result = p.parseExpression(pLowest)
+	if p.err != nil {
+		return nil
+	}
+	return arm
+}
+
+// skipArmSeparators eats commas and new lines between arms of match
+func (p *Parser) skipArmSeparators() {
+	for p.oneOf(token.COMMA, token.SEMICOLON) {
+		p.next()
+	}
+}
+
+// parseSwitchExpression parses `switch $subject { case 1: ... default: ... }`,
+// break leaves switch as it leaves loops
+func (p *Parser) parseSwitchExpression() ast.Expression {
+	se := &ast.SwitchExpression{Token: p.curToken}
+	p.next() // eat `switch`
+
+	se.Subject = p.parseExpression(pLowest)
+	p.eatOfType(token.CURLY_OPENING)
+	if p.err != nil {
+		return nil
+	}
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+
+	hasDefault := false
+	for !p.oneOf(token.CURLY_CLOSING) {
+		cc := &ast.CaseClause{Token: p.curToken}
+		switch p.curToken.Type {
+		case token.CASE:
+			p.next() // eat `case`
+			cc.Value = p.parseExpression(pLowest)
+		case token.DEFAULT:
+			if hasDefault {
+				p.emitError("switch can have only one default case")
+				return nil
+			}
+			hasDefault = true
+			p.next() // eat `default`
+		default:
+			p.emitError("expected case or default, %s given", p.curToken.Literal)
+			return nil
+		}
+		p.eatOfType(token.COLON)
+		if p.err != nil {
+			return nil
+		}
+		if cc.Body = p.parseCaseBody(); cc.Body == nil {
+			return nil
+		}
+		se.Cases = append(se.Cases, cc)
+	}
+	p.next() // eat `}`
+
+	return se
+}
+
+// parseCaseBody parses statements of a case up to the next case or the end of switch
+func (p *Parser) parseCaseBody() *ast.BlockStatement {
+	body := &ast.BlockStatement{Token: p.curToken}
+	for !p.oneOf(token.CASE, token.DEFAULT, token.CURLY_CLOSING) {
+		st := p.parseStatement()
+		if st == nil || p.err != nil {
+			return nil
+		}
+		body.Statements = append(body.Statements, st)
+	}
+	return body
+}
+
+// parseSpreadExpression parses `...$values` unpacking an array into call arguments
+func (p *Parser) parseSpreadExpression() ast.Expression {
+	se := &ast.SpreadExpression{Token: p.curToken}
+	p.next() // eat `...`
+	se.Value = p.parseExpression(pLowest)
+
+	return se
+}
+
+// parseThrowExpression parses `throw $exception`
+func (p *Parser) parseThrowExpression() ast.Expression {
+	te := &ast.ThrowExpression{Token: p.curToken}
+	p.next() // eat `throw`
+	te.Value = p.parseExpression(pLowest)
+
+	return te
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	// eat `(
 	p.next()
@@ -681,21 +1503,30 @@ func (p *Parser) parseIdentifier() ast.Expression {
 }
 
 func (p *Parser) parseInteger() ast.Expression {
-	defer p.next() // eat NUMBER
-
-	value, err := strconv.ParseInt(p.curToken.Literal, 10, 64)
+	tok := p.curToken
+	value, err := strconv.ParseInt(tok.Literal, 10, 64)
 	if err != nil {
-		p.emitError(err.Error())
+		p.emitError("%s", err)
 		return nil
 	}
-
+	p.next() // eat NUMBER
+
+	// the sign is a part of the literal, but `-2 ** 2` is `-(2 ** 2)`
+	if value < 0 && p.curToken.Type == token.POW {
+		return &ast.UnaryExpression{
+			Token:    tok,
+			Op:       "-",
+			IsPrefix: true,
+			Right:    p.parseRightAssociativeExpression(&ast.IntegerLiteral{Value: -value}),
+		}
+	}
 	return &ast.IntegerLiteral{Value: value}
 }
 
 func (p *Parser) parseInstanceOfExpression(left ast.Expression) ast.Expression {
-	iof := ast.InstanceOfExpression{Object: left, Token: p.curToken}
+	iof := &ast.InstanceOfExpression{Object: left, Token: p.curToken}
 	p.next() // eat `instanceof`
-	iof.Type = p.parseExpression(pLowest)
+	iof.Type = p.parseExpression(pPrefix)
 
 	return iof
 }
@@ -725,8 +1556,20 @@ func (p *Parser) parseExpressionList() []ast.Expression {
 		return list
 	}
 
+	named := false
 	for {
-		list = append(list, p.parseExpression(pLowest))
+		if p.oneOf(token.IDENT) && p.peek().Type == token.COLON {
+			named = true
+			list = append(list, p.parseNamedArgument())
+		} else if named {
+			p.emitError("positional argument can not follow named arguments")
+			return nil
+		} else {
+			list = append(list, p.parseExpression(pLowest))
+		}
+		if p.err != nil {
+			return nil
+		}
 		if p.curToken.Type == token.PARENTHESIS_CLOSING {
 			p.next() // eat `)`
 			break
@@ -739,22 +1582,79 @@ func (p *Parser) parseExpressionList() []ast.Expression {
 	return list
 }
 
+// parseNamedArgument parses `name: $value` in call arguments
+func (p *Parser) parseNamedArgument() ast.Expression {
+	na := &ast.NamedArgument{Token: p.curToken}
+	na.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.next() // eat IDENT
+	p.next() // eat `:`
+	na.Value = p.parseExpression(pLowest)
+
+	return na
+}
+
 func (p *Parser) parseFetchExpression(left ast.Expression) ast.Expression {
-	fe := &ast.FetchExpression{Token: p.curToken}
+	fe := &ast.FetchExpression{Token: p.curToken, IsNullsafe: p.curToken.Type == token.NULLSAFE_OBJECT_OPERATOR}
 	p.next() // eat `->`
 
 	fe.Left = left
-	fe.Right = p.parseExpression(precedences[fe.Token.Type])
+	// only the member is fetched here, `[` and `(` after a property apply to its value
+	if !p.oneOf(token.IDENT) {
+		p.emitError("expected a property or a method name after %s, %s given", fe.Token.Literal, p.curToken.Literal)
+		return nil
+	}
+	name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	p.next() // eat IDENT
+	if p.oneOf(token.PARENTHESIS_OPENING) {
+		fe.Right = p.parseFunctionCall(name)
+	} else {
+		fe.Right = name
+	}
 
-	switch fe.Right.(type) {
-	case *ast.FunctionCall:
-	case *ast.Identifier:
+	return fe
+}
+
+// parseStaticFetchExpression parses `parent::method($args)`
+func (p *Parser) parseStaticFetchExpression(left ast.Expression) ast.Expression {
+	sfe := &ast.StaticFetchExpression{Token: p.curToken}
+	p.next() // eat `::`
+
+	if _, ok := left.(*ast.Identifier); !ok {
+		p.emitErrorInPos(sfe.Token.Pos, "expected class name before ::, %s given", left.String())
+		return nil
+	}
+	sfe.Left = left
+	// method, constant or static property, `[` and `->` after it apply to its value
+	switch p.curToken.Type {
+	case token.IDENT:
+		name := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.next() // eat IDENT
+		if p.oneOf(token.PARENTHESIS_OPENING) {
+			sfe.Right = p.parseFunctionCall(name)
+		} else {
+			sfe.Right = name
+		}
+	case token.VAR:
+		sfe.Right = p.parseVariable()
 	default:
-		p.emitError("unexpected either an Identifier or a FunctionCall, %s given", reflect.TypeOf(fe.Right).String())
+		p.emitErrorInPos(sfe.Token.Pos, "expected a method, constant or static property after ::, %s given", p.curToken.Literal)
+		return nil
+	}
+	if p.err != nil {
 		return nil
 	}
 
-	return fe
+	return sfe
+}
+
+// parseStatic parses either `static::` reference to the called class or a static member declaration
+func (p *Parser) parseStatic() ast.Expression {
+	if p.peek().Type == token.PAAMAYIM_NEKUDOTAYIM {
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.next() // eat `static`
+		return ident
+	}
+	return p.parseModifiedExpression()
 }
 
 // parseModifiedExpression ...
@@ -765,10 +1665,29 @@ func (p *Parser) parseModifiedExpression() ast.Expression {
 		flags[modifier(p.curToken.Type)] = true
 		p.next() // eat <MODIFIER>
 	}
-	modified := p.parseExpression(pLowest)
+
+	var modified ast.Expression
+	switch p.curToken.Type {
+	case token.FUNCTION:
+		inStatic := p.inStaticMethod
+		p.inStaticMethod = flags[ast.ModStatic]
+		modified = p.parseMethodDeclaration(tok)
+		p.inStaticMethod = inStatic
+	case token.VAR:
+		modified = p.parsePropertyDeclaration(tok)
+	default:
+		modified = p.parseExpression(pLowest)
+	}
+	if p.err != nil {
+		return nil
+	}
 
 	switch m := modified.(type) {
 	case *ast.ClassDeclarationExpression:
+		if flags[ast.ModStatic] {
+			p.emitErrorInPos(tok.Pos, "class %s can not be static", m.Name.Value)
+			return nil
+		}
 		m.IsAbstract = flags[ast.ModAbstract] == true
 		delete(flags, ast.ModAbstract)
 		m.IsFinal = flags[ast.ModFinal] == true
@@ -778,6 +1697,8 @@ func (p *Parser) parseModifiedExpression() ast.Expression {
 		delete(flags, ast.ModAbstract)
 		m.IsFinal = flags[ast.ModFinal] == true
 		delete(flags, ast.ModFinal)
+		m.IsStatic = flags[ast.ModStatic] == true
+		delete(flags, ast.ModStatic)
 		// @todo optimize
 		for _, mod := range accessModifiers {
 			if _, ok := flags[mod]; ok {
@@ -786,6 +1707,25 @@ func (p *Parser) parseModifiedExpression() ast.Expression {
 				break
 			}
 		}
+	case *ast.PropertyDeclarationExpression:
+		if flags[ast.ModAbstract] || flags[ast.ModFinal] {
+			p.emitErrorInPos(tok.Pos, "property %s can not be abstract or final", m.Name.String())
+			return nil
+		}
+		m.IsStatic = flags[ast.ModStatic] == true
+		for _, mod := range accessModifiers {
+			if _, ok := flags[mod]; ok {
+				m.Access = mod
+				break
+			}
+		}
+	case *ast.AssignmentExpression:
+		// `public const X = 1`, constants are always public
+		_, isConst := m.Left.(*ast.ConstantExpression)
+		if !isConst || flags[ast.ModAbstract] || flags[ast.ModFinal] || flags[ast.ModStatic] {
+			p.emitErrorInPos(tok.Pos, "unexpected modifier for %v", modified)
+			return nil
+		}
 	default:
 		p.emitErrorInPos(tok.Pos, "unexpected modifier for %v", modified)
 	}
@@ -138,3 +138,491 @@ func TestParser_ParseUseStatement(t *testing.T) {
 		ast.UseStatement{Namespace: "Symfony\\Component\\Debug\\Exception", Classes: []string{"FlattenException"}},
 	})
 }
+
+func TestParser_ParseClassDeclaration(t *testing.T) {
+	input := []rune(`class Dog {
+		public $name = "Rex"
+		private $age
+
+		public function __construct($name) { $this->name = $name }
+		protected function bark() { println("bark!") }
+	}`)
+	scn := scanner.New(input)
+	parser := New(scn, error.NewFormatter("<test>", input))
+
+	program, e := parser.Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	class := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ClassDeclarationExpression)
+	if len(class.Block.Statements) != 4 {
+		t.Fatalf("expected 4 members, got %d", len(class.Block.Statements))
+	}
+	property := class.Block.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.PropertyDeclarationExpression)
+	if property.Access != ast.ModPrivate || property.Name.Name != "age" {
+		t.Errorf("unexpected property %v", property)
+	}
+	method := class.Block.Statements[3].(*ast.ExpressionStatement).Expression.(*ast.MethodDeclarationExpression)
+	if method.Access != ast.ModProtected || method.Name.Value != "bark" {
+		t.Errorf("unexpected method %v", method)
+	}
+}
+
+func TestParser_ParseStaticMembers(t *testing.T) {
+	input := []rune(`class Counter {
+		const START = 0
+		public static $count = self::START
+
+		public static function next() { static::$count = static::$count + 1 }
+	}
+	Counter::next()`)
+	scn := scanner.New(input)
+	parser := New(scn, error.NewFormatter("<test>", input))
+
+	program, e := parser.Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	class := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ClassDeclarationExpression)
+	property := class.Block.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.PropertyDeclarationExpression)
+	if !property.IsStatic || property.DefaultValue.String() != "self::START" {
+		t.Errorf("unexpected property %v", property)
+	}
+	method := class.Block.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.MethodDeclarationExpression)
+	if !method.IsStatic || method.Name.Value != "next" {
+		t.Errorf("unexpected method %v", method)
+	}
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.StaticFetchExpression)
+	if _, ok := call.Right.(*ast.FunctionCall); !ok {
+		t.Errorf("expected a method call, got %v", call.Right)
+	}
+}
+
+func TestParser_ParseTryExpression(t *testing.T) {
+	input := []rune(`$x = try {
+		open()
+	} catch (os\Exception $e) {
+		0
+	}
+	finally { close() }`)
+	scn := scanner.New(input)
+	parser := New(scn, error.NewFormatter("<test>", input))
+
+	program, e := parser.Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	assignment := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignmentExpression)
+	try := assignment.Right.(*ast.TryExpression)
+	if len(try.Catches) != 1 || try.Catches[0].Type.Value != `os\Exception` || try.Catches[0].Variable.Name != "e" {
+		t.Errorf("unexpected catch clauses %v", try.Catches)
+	}
+	if try.Finally == nil {
+		t.Error("expected finally block")
+	}
+}
+
+func TestParser_ParseWhile(t *testing.T) {
+	inputs := []string{
+		`while $i < $n { $i = $i + 1 }`,
+		`while ($i < $n) { $i = $i + 1 }`,
+		`do { $i = $i + 1 } while $i < $n`,
+		`do { $i = $i + 1 } while ($i < $n)`,
+	}
+	for _, in := range inputs {
+		input := []rune(in)
+		parser := New(scanner.New(input), error.NewFormatter("<test>", input))
+		program, e := parser.Parse()
+		if e != nil {
+			t.Fatal(e)
+		}
+		var condition ast.Expression
+		switch loop := program.Statements[0].(*ast.ExpressionStatement).Expression.(type) {
+		case *ast.WhileExpression:
+			condition = loop.Condition
+		case *ast.DoWhileExpression:
+			condition = loop.Condition
+		default:
+			t.Fatalf("expected a loop, got %v", loop)
+		}
+		if condition.String() != "$i < $n" {
+			t.Errorf("unexpected condition %s in %s", condition.String(), in)
+		}
+	}
+}
+
+func TestParser_ParseFor(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`for ($i = 0, $j = $n; $i < $j; $i = $i + 1, $j = $j - 1) {}`, "for ($i = 0, $j = $n; $i < $j; $i = $i + 1, $j = $j - 1) "},
+		{`for $i = 0; $i < $n; $i = $i + 1 {}`, "for ($i = 0; $i < $n; $i = $i + 1) "},
+		{`for (;;) {}`, "for (; ; ) "},
+	}
+	for _, tt := range tests {
+		input := []rune(tt.input)
+		parser := New(scanner.New(input), error.NewFormatter("<test>", input))
+		program, e := parser.Parse()
+		if e != nil {
+			t.Fatal(e)
+		}
+		loop, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.ForExpression)
+		if !ok {
+			t.Fatalf("expected a for loop, got %v", program.Statements[0])
+		}
+		if !strings.HasPrefix(loop.String(), tt.expected) {
+			t.Errorf("expected %q to start with %q", loop.String(), tt.expected)
+		}
+	}
+}
+
+func TestParser_ParseLoopControl(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while true { break }", ""},
+		{"foreach $a as $b { while true { continue 2 } }", ""},
+		{"break", "break is not in a loop"},
+		{"while true { function f() { continue } }", "continue is not in a loop"},
+		{"while true { break 2 }", "can not break 2 levels, only 1 enclosing loops or switches"},
+	}
+	for _, tt := range tests {
+		input := []rune(tt.input + "\n")
+		parser := New(scanner.New(input), error.NewFormatter("<test>", input))
+		_, e := parser.Parse()
+		if tt.expected == "" {
+			if e != nil {
+				t.Errorf("%s: unexpected error %v", tt.input, e)
+			}
+			continue
+		}
+		if e == nil || !strings.Contains(e.Error(), tt.expected) {
+			t.Errorf("%s: expected error %q, got %v", tt.input, tt.expected, e)
+		}
+	}
+}
+
+func TestParser_ParseMatchExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match ($x) { 1, 2 => "low", Int if $x > 10 => "big", default => "other" }`,
+			`match ($x) { 1, 2 => 'low', Int if $x > 10 => 'big', default => 'other' }`},
+		{"match $x {\n\t\"a\" => 1\n\tdefault => 2\n}", "match ($x) { 'a' => 1, default => 2 }"},
+	}
+	for _, tt := range tests {
+		input := []rune(tt.input + "\n")
+		parser := New(scanner.New(input), error.NewFormatter("<test>", input))
+		program, e := parser.Parse()
+		if e != nil {
+			t.Fatal(e)
+		}
+		match, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MatchExpression)
+		if !ok {
+			t.Fatalf("expected match, got %v", program.Statements[0])
+		}
+		if match.String() != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, match.String())
+		}
+	}
+
+	input := []rune("match ($x) { default => 1, default => 2 }\n")
+	_, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "only one default arm") {
+		t.Errorf("expected duplicate default error, got %v", e)
+	}
+}
+
+func TestParser_ParseSwitchExpression(t *testing.T) {
+	input := []rune("switch ($x) {\ncase 1:\ncase 2:\n\t$y = 1\n\tbreak\ndefault:\n\t$y = 2\n}\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	se, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SwitchExpression)
+	if !ok {
+		t.Fatalf("expected switch, got %v", program.Statements[0])
+	}
+	if len(se.Cases) != 3 || len(se.Cases[0].Body.Statements) != 0 || len(se.Cases[1].Body.Statements) != 2 {
+		t.Errorf("unexpected cases in %s", se.String())
+	}
+	if !se.Cases[2].IsDefault() {
+		t.Errorf("expected default case, got %s", se.Cases[2].String())
+	}
+
+	input = []rune("switch ($x) { default: 1; default: 2 }\n")
+	_, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "only one default case") {
+		t.Errorf("expected duplicate default error, got %v", e)
+	}
+}
+
+func TestParser_ParseClosureUse(t *testing.T) {
+	input := []rune("$f = function($x) use ($a, &$b) { $x }\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	assignment := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignmentExpression)
+	fun := assignment.Right.(*ast.FunctionDeclarationExpression)
+	if len(fun.BindVars) != 2 || fun.BindVars[0].IsReference || !fun.BindVars[1].IsReference {
+		t.Fatalf("unexpected bound variables %v", fun.BindVars)
+	}
+	if !strings.HasPrefix(fun.String(), "function($x) use ($a, &$b)") {
+		t.Errorf("unexpected closure %s", fun.String())
+	}
+
+	input = []rune("function named() use ($a) {}\n")
+	_, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "only anonymous functions") {
+		t.Errorf("expected error for named function with use, got %v", e)
+	}
+}
+
+func TestParser_ParseThisInStaticMethod(t *testing.T) {
+	input := []rune("class A {\npublic static function f() { $this->g() }\n}\n")
+	_, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "can not use $this in a static method") {
+		t.Errorf("expected error for $this in static method, got %v", e)
+	}
+
+	input = []rune("class A {\npublic static function f() { new A() }\npublic function g() { $this }\n}\n")
+	if _, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse(); e != nil {
+		t.Errorf("unexpected error %v", e)
+	}
+}
+
+func TestParser_ParseDefaultArgs(t *testing.T) {
+	input := []rune("function f($a, Int $b = 1, $c = $b + 1) {}\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	fun := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionDeclarationExpression)
+	expected := []string{"$a", "Int $b = 1", "$c = $b + 1"}
+	if len(fun.Args) != len(expected) {
+		t.Fatalf("expected %d args, got %d", len(expected), len(fun.Args))
+	}
+	for i, arg := range fun.Args {
+		if arg.String() != expected[i] {
+			t.Errorf("expected arg %q, got %q", expected[i], arg.String())
+		}
+	}
+}
+
+func TestParser_ParseVariadic(t *testing.T) {
+	input := []rune("function f($a, Int ...$rest) {}\nf(1, ...$values)\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	fun := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionDeclarationExpression)
+	if !fun.Args[1].Variadic || fun.Args[1].String() != "Int ...$rest" {
+		t.Errorf("expected variadic arg, got %s", fun.Args[1].String())
+	}
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.FunctionCall)
+	if _, ok := call.CallArgs[1].(*ast.SpreadExpression); !ok {
+		t.Errorf("expected unpacked argument, got %v", call.CallArgs[1])
+	}
+
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"function f(...$rest, $a) {}\n", "variadic argument $rest must be the last one"},
+		{"function f(...$rest = 1) {}\n", "variadic argument $rest can not have a default value"},
+	}
+	for _, tt := range tests {
+		input := []rune(tt.input)
+		_, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+		if e == nil || !strings.Contains(e.Error(), tt.err) {
+			t.Errorf("expected error %q, got %v", tt.err, e)
+		}
+	}
+}
+
+func TestParser_ParseNamedArgs(t *testing.T) {
+	input := []rune("makeUser(\"x\", admin: true)\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	call := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionCall)
+	named, ok := call.CallArgs[1].(*ast.NamedArgument)
+	if !ok || named.String() != "admin: true" {
+		t.Errorf("expected named argument, got %v", call.CallArgs[1])
+	}
+
+	input = []rune("makeUser(name: \"x\", true)\n")
+	_, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "positional argument can not follow named arguments") {
+		t.Errorf("expected error for positional argument after named, got %v", e)
+	}
+}
+
+func TestParser_ParseCompoundAssignment(t *testing.T) {
+	input := []rune("$a[0] **= 2\n$s .= \"x\"\n$x ??= 1\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i, op := range []string{"**", ".", "??"} {
+		as, ok := program.Statements[i].(*ast.ExpressionStatement).Expression.(*ast.AssignmentExpression)
+		if !ok || as.Operator != op {
+			t.Errorf("statement %d: expected assignment with operator %s, got %v", i, op, program.Statements[i])
+		}
+	}
+
+	input = []rune("$o->run() += 1\n")
+	_, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "can not assign to") {
+		t.Errorf("expected error for assignment to a method call, got %v", e)
+	}
+}
+
+func TestParser_ParseIncDec(t *testing.T) {
+	input := []rune("$i++\n--$o->count\n$a[0]--\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i, expected := range []string{"$i++", "--$o->count", "$a[0]--"} {
+		ue, ok := program.Statements[i].(*ast.ExpressionStatement).Expression.(*ast.UnaryExpression)
+		if !ok || ue.String() != expected {
+			t.Errorf("statement %d: expected %s, got %v", i, expected, program.Statements[i])
+		}
+	}
+
+	input = []rune("++foo()\n")
+	_, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "can not increment foo()") {
+		t.Errorf("expected error for incrementing a call, got %v", e)
+	}
+}
+
+func TestParser_ParseUnaryExpression(t *testing.T) {
+	input := []rune("!$o->valid() + -$x * 2\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	be, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+	if !ok {
+		t.Fatalf("expected binary expression, got %v", program.Statements[0])
+	}
+	if not, ok := be.Left.(*ast.UnaryExpression); !ok || not.Op != "!" || not.String() != "!$o->valid()" {
+		t.Errorf("expected negated method call, got %v", be.Left)
+	}
+	if mul, ok := be.Right.(*ast.BinaryExpression); !ok || mul.Op != "*" {
+		t.Errorf("expected -$x * 2, got %v", be.Right)
+	}
+}
+
+func TestParser_ParseLogicalPrecedence(t *testing.T) {
+	tests := []struct {
+		input string
+		op    string
+		left  string
+	}{
+		{"$a || $b && $c\n", "||", "$a"},
+		{"$a or $b and $c\n", "or", "$a"},
+		{"$x = $a and $b\n", "and", "$x = $a"},
+		{"$a < 1 && $b\n", "&&", "$a < 1"},
+	}
+	for _, tt := range tests {
+		input := []rune(tt.input)
+		program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+		if e != nil {
+			t.Fatal(e)
+		}
+		be, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+		if !ok || be.Op != tt.op || be.Left.String() != tt.left {
+			t.Errorf("%q: expected %s with left side %s, got %v", tt.input, tt.op, tt.left, program.Statements[0])
+		}
+	}
+}
+
+func TestParser_ParseCoalesceAndNullsafe(t *testing.T) {
+	input := []rune("$a ?? $b ?? $c\n$o?->next()?->name\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	be, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+	if !ok || be.Op != "??" || be.Left.String() != "$a" {
+		t.Errorf("expected right associative ??, got %v", program.Statements[0])
+	}
+	fe, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.FetchExpression)
+	if !ok || !fe.IsNullsafe || fe.String() != "$o?->next()?->name" {
+		t.Errorf("expected nullsafe fetch, got %v", program.Statements[1])
+	}
+
+	input = []rune("$o?->name = 1\n")
+	_, e = New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e == nil || !strings.Contains(e.Error(), "can not assign to") {
+		t.Errorf("expected error for assignment to a nullsafe fetch, got %v", e)
+	}
+}
+
+func TestParser_ParseComparisonPrecedence(t *testing.T) {
+	input := []rune("$a < $b == $c !== $d\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	be, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+	if !ok || be.Op != "!==" {
+		t.Fatalf("expected !== at the top, got %v", program.Statements[0])
+	}
+	if eq, ok := be.Left.(*ast.BinaryExpression); !ok || eq.Op != "==" || eq.Left.String() != "$a < $b" {
+		t.Errorf("expected relational operator to bind tighter than ==, got %v", be.Left)
+	}
+}
+
+func TestParser_ParsePowPrecedence(t *testing.T) {
+	input := []rune("2 ** 3 ** 2\n-2 ** 2\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	be, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+	if !ok || be.Op != "**" || be.Left.String() != "2" || be.Right.String() != "3 ** 2" {
+		t.Errorf("expected right associative **, got %v", program.Statements[0])
+	}
+	ue, ok := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.UnaryExpression)
+	if !ok || ue.Op != "-" || ue.Right.String() != "2 ** 2" {
+		t.Errorf("expected negated power, got %v", program.Statements[1])
+	}
+}
+
+func TestParser_ParseConcatPrecedence(t *testing.T) {
+	input := []rune("\"a\" . $b + 1 == \"a2\"\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	be, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.BinaryExpression)
+	if !ok || be.Op != "==" {
+		t.Fatalf("expected == at the top, got %v", program.Statements[0])
+	}
+	if concat, ok := be.Left.(*ast.BinaryExpression); !ok || concat.Op != "." || concat.Right.String() != "$b + 1" {
+		t.Errorf("expected + to bind tighter than ., got %v", be.Left)
+	}
+}
+
+func TestParser_ParseFetchIndex(t *testing.T) {
+	input := []rune("$o->a[0]\n$o->m()[1]\nA::$b[2]\n")
+	program, e := New(scanner.New(input), error.NewFormatter("<test>", input)).Parse()
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i, left := range []string{"$o->a", "$o->m()", "A::$b"} {
+		ie, ok := program.Statements[i].(*ast.ExpressionStatement).Expression.(*ast.IndexExpression)
+		if !ok || ie.Left.String() != left {
+			t.Errorf("expected %s to be indexed, got %v", left, program.Statements[i])
+		}
+	}
+}
@@ -11,6 +11,7 @@ var modifierTable = map[token.TokenType]int32{
 	token.PRIVATE:   ast.ModPrivate,
 	token.FINAL:     ast.ModFinal,
 	token.ABSTRACT:  ast.ModAbstract,
+	token.STATIC:    ast.ModStatic,
 }
 
 func modifier(t token.TokenType) int32 {
@@ -24,3 +25,28 @@ func isModifier(t token.TokenType) bool {
 	_, ok := modifierTable[t]
 	return ok
 }
+
+// isAssignable reports whether e can be the left side of an assignment:
+// a variable, an array element, a property or a static property
+func isAssignable(e ast.Expression) bool {
+	switch e := e.(type) {
+	case *ast.VariableExpression, *ast.IndexExpression:
+		return true
+	case *ast.FetchExpression:
+		// only properties are assignable, not method calls nor nullsafe fetches
+		_, ok := e.Right.(*ast.Identifier)
+		return ok && !e.IsNullsafe
+	case *ast.StaticFetchExpression:
+		// only static properties are assignable
+		_, ok := e.Right.(*ast.VariableExpression)
+		return ok
+	}
+	return false
+}
+
+func incDecVerb(op string) string {
+	if op == "++" {
+		return "increment"
+	}
+	return "decrement"
+}
@@ -13,7 +13,11 @@ var tokens = map[string]token.TokenType{
 	"use":        token.USE,
 	"final":      token.FINAL,
 	"abstract":   token.ABSTRACT,
+	"static":     token.STATIC,
 	"class":      token.CLASS,
+	"interface":  token.INTERFACE,
+	"trait":      token.TRAIT,
+	"insteadof":  token.INSTEADOF,
 	"implements": token.IMPLEMENTS,
 	"protected":  token.PROTECTED,
 	"public":     token.PUBLIC,
@@ -24,9 +28,24 @@ var tokens = map[string]token.TokenType{
 	"else":       token.ELSE,
 	"extends":    token.EXTENDS,
 	"foreach":    token.FOREACH,
+	"for":        token.FOR,
+	"while":      token.WHILE,
+	"do":         token.DO,
+	"break":      token.BREAK,
+	"continue":   token.CONTINUE,
+	"match":      token.MATCH,
+	"default":    token.DEFAULT,
+	"switch":     token.SWITCH,
+	"case":       token.CASE,
 	"instanceof": token.INSTANCEOF,
+	"and":        token.LOGICAL_AND,
+	"or":         token.LOGICAL_OR,
+	"xor":        token.LOGICAL_XOR,
 	"const":      token.CONST,
 	"throw":      token.THROW,
+	"try":        token.TRY,
+	"catch":      token.CATCH,
+	"finally":    token.FINALLY,
 	"new":        token.NEW,
 }
 
@@ -36,6 +55,7 @@ var (
 	tokenIllegal = token.Token{Type: token.ILLEGAL}
 
 	tokenDoubleDot = token.Token{Type: token.DOUBLE_DOT, Literal: ".."}
+	tokenEllipsis  = token.Token{Type: token.ELLIPSIS, Literal: "..."}
 
 	// arithmetic
 	tokenPlus        = token.Token{Type: token.PLUS, Literal: "+"}
@@ -48,6 +68,14 @@ var (
 	tokenSmaller     = token.Token{Type: token.IS_SMALLER, Literal: "<"}
 	tokenGreater     = token.Token{Type: token.IS_GREATER, Literal: ">"}
 	tokenMultiAssign = token.Token{Type: token.MUL_EQUAL, Literal: "*="}
+	tokenPow         = token.Token{Type: token.POW, Literal: "**"}
+	tokenPowAssign   = token.Token{Type: token.POW_EQUAL, Literal: "**="}
+
+	tokenConcatAssign = token.Token{Type: token.CONCAT_EQUAL, Literal: ".="}
+	tokenConcat       = token.Token{Type: token.CONCAT, Literal: "."}
+
+	tokenCoalesce       = token.Token{Type: token.COALESCE, Literal: "??"}
+	tokenCoalesceAssign = token.Token{Type: token.COALESCE_EQUAL, Literal: "??="}
 
 	tokenSubAssign = token.Token{Type: token.MINUS_EQUAL, Literal: "-="}
 	tokenDecrement = token.Token{Type: token.DEC, Literal: "--"}
@@ -61,7 +89,8 @@ var (
 	tokenIncrement = token.Token{Type: token.INC, Literal: "++"}
 
 	tokenSmallerOrEqual = token.Token{Type: token.IS_SMALLER_OR_EQUAL, Literal: "<="}
-	tokenGreaterOrEqual = token.Token{Type: token.IS_GREATER_OR_EQUAL, Literal: "<="}
+	tokenGreaterOrEqual = token.Token{Type: token.IS_GREATER_OR_EQUAL, Literal: ">="}
+	tokenSpaceship      = token.Token{Type: token.SPACESHIP, Literal: "<=>"}
 
 	tokenAssign      = token.Token{Type: token.EQUAL, Literal: "="}
 	tokenDoubleArrow = token.Token{Type: token.DOUBLE_ARROW, Literal: "=>"}
@@ -71,14 +100,32 @@ var (
 	tokenSemicolon = token.Token{Type: token.SEMICOLON, Literal: ";"}
 	tokenColon     = token.Token{Type: token.COLON, Literal: ":"}
 
-	tokenNot = token.Token{Type: token.NOT, Literal: "!"}
+	tokenNot        = token.Token{Type: token.NOT, Literal: "!"}
+	tokenBitwiseNot = token.Token{Type: token.BITWISE_NOT, Literal: "~"}
+
+	tokenAmpersand = token.Token{Type: token.AMPERSAND, Literal: "&"}
+
+	tokenBooleanAnd = token.Token{Type: token.BOOLEAN_AND, Literal: "&&"}
+	tokenBooleanOr  = token.Token{Type: token.BOOLEAN_OR, Literal: "||"}
+
+	tokenBitwiseOr        = token.Token{Type: token.BITWISE_OR, Literal: "|"}
+	tokenBitwiseXor       = token.Token{Type: token.BITWISE_XOR, Literal: "^"}
+	tokenAndAssign        = token.Token{Type: token.AND_EQUAL, Literal: "&="}
+	tokenOrAssign         = token.Token{Type: token.OR_EQUAL, Literal: "|="}
+	tokenXorAssign        = token.Token{Type: token.XOR_EQUAL, Literal: "^="}
+	tokenShiftLeft        = token.Token{Type: token.SL, Literal: "<<"}
+	tokenShiftRight       = token.Token{Type: token.SR, Literal: ">>"}
+	tokenShiftLeftAssign  = token.Token{Type: token.SL_EQUAL, Literal: "<<="}
+	tokenShiftRightAssign = token.Token{Type: token.SR_EQUAL, Literal: ">>="}
 
 	tokenEqual        = token.Token{Type: token.IS_EQUAL, Literal: "=="}
-	tokenIdentical    = token.Token{Type: token.IS_EQUAL, Literal: "==="}
+	tokenIdentical    = token.Token{Type: token.IS_IDENTICAL, Literal: "==="}
 	tokenNotEqual     = token.Token{Type: token.IS_NOT_EQUAL, Literal: "!="}
 	tokenNotIdentical = token.Token{Type: token.IS_NOT_IDENTICAL, Literal: "!=="}
 
 	tokenFetch       = token.Token{Type: token.OBJECT_OPERATOR, Literal: "->"}
+	tokenNullsafe    = token.Token{Type: token.NULLSAFE_OBJECT_OPERATOR, Literal: "?->"}
+	tokenQuestion    = token.Token{Type: token.QUESTION_MARK, Literal: "?"}
 	tokenStaticFetch = token.Token{Type: token.PAAMAYIM_NEKUDOTAYIM, Literal: "::"}
 	tokenBackslash   = token.Token{Type: token.BACKSLASH, Literal: "\\"}
 
@@ -104,6 +151,9 @@ type Scanner struct {
 	src        []rune
 	len        int
 	insertSemi bool
+	// memberName is set after `$`, `->`, `?->`, `::` and `function`,
+	// keywords are names of variables, members or functions there
+	memberName bool
 	offset     int
 	ch         rune
 }
@@ -154,8 +204,30 @@ func (s *Scanner) Next() (tok token.Token) {
 		if s.peek() == '.' {
 			s.next() // eat `.`
 			tok = tokenDoubleDot
+			if s.peek() == '.' {
+				s.next() // eat `.`
+				tok = tokenEllipsis
+			}
+		} else if s.peek() == '=' {
+			s.next()
+			tok = tokenConcatAssign
 		} else {
-			tok = tokenIllegal
+			tok = tokenConcat
+		}
+	case '?':
+		if s.peek() == '?' {
+			s.next() // eat `?`
+			tok = tokenCoalesce
+			if s.peek() == '=' {
+				s.next()
+				tok = tokenCoalesceAssign
+			}
+		} else if s.peek() == '-' && s.offset+2 < s.len && s.src[s.offset+2] == '>' {
+			s.next() // eat `-`
+			s.next() // eat `>`
+			tok = tokenNullsafe
+		} else {
+			tok = tokenQuestion
 		}
 	case ';':
 		tok = tokenSemicolon
@@ -190,6 +262,17 @@ func (s *Scanner) Next() (tok token.Token) {
 		if s.peek() == '=' {
 			s.next()
 			tok = tokenSmallerOrEqual
+			if s.peek() == '>' {
+				s.next()
+				tok = tokenSpaceship
+			}
+		} else if s.peek() == '<' {
+			s.next() // eat `<`
+			tok = tokenShiftLeft
+			if s.peek() == '=' {
+				s.next()
+				tok = tokenShiftLeftAssign
+			}
 		} else {
 			tok = tokenSmaller
 		}
@@ -197,6 +280,13 @@ func (s *Scanner) Next() (tok token.Token) {
 		if s.peek() == '=' {
 			s.next()
 			tok = tokenGreaterOrEqual
+		} else if s.peek() == '>' {
+			s.next() // eat `>`
+			tok = tokenShiftRight
+			if s.peek() == '=' {
+				s.next()
+				tok = tokenShiftRightAssign
+			}
 		} else {
 			tok = tokenGreater
 		}
@@ -224,6 +314,35 @@ func (s *Scanner) Next() (tok token.Token) {
 		tok = tokenCurlyClose
 	case '$':
 		tok = tokenVariable
+	case '&':
+		if s.peek() == '&' {
+			s.next()
+			tok = tokenBooleanAnd
+		} else if s.peek() == '=' {
+			s.next()
+			tok = tokenAndAssign
+		} else {
+			tok = tokenAmpersand
+		}
+	case '|':
+		if s.peek() == '|' {
+			s.next()
+			tok = tokenBooleanOr
+		} else if s.peek() == '=' {
+			s.next()
+			tok = tokenOrAssign
+		} else {
+			tok = tokenBitwiseOr
+		}
+	case '^':
+		if s.peek() == '=' {
+			s.next()
+			tok = tokenXorAssign
+		} else {
+			tok = tokenBitwiseXor
+		}
+	case '~':
+		tok = tokenBitwiseNot
 	case '+':
 		if s.peek() == '+' {
 			s.next()
@@ -237,7 +356,8 @@ func (s *Scanner) Next() (tok token.Token) {
 		}
 	case '-':
 		next := s.peek()
-		if unicode.IsDigit(next) {
+		// after an operand `-` is a subtraction, e.g. `$x-1`
+		if unicode.IsDigit(next) && !s.insertSemi {
 			tok = s.scanNumber(true)
 		} else if s.peek() == '>' {
 			s.next()
@@ -255,7 +375,14 @@ func (s *Scanner) Next() (tok token.Token) {
 	case '\n':
 		tok = tokenSemicolon
 	case '*':
-		if s.peek() == '=' {
+		if s.peek() == '*' {
+			s.next() // eat `*`
+			tok = tokenPow
+			if s.peek() == '=' {
+				s.next()
+				tok = tokenPowAssign
+			}
+		} else if s.peek() == '=' {
 			s.next()
 			tok = tokenMultiAssign
 		} else {
@@ -279,7 +406,7 @@ func (s *Scanner) Next() (tok token.Token) {
 			tok = s.scanNumber(false)
 		case s.isIdentifier(s.ch):
 			tok = s.scanIdentifier()
-			if tok.Type == token.RETURN || tok.Type == token.IDENT {
+			if tok.Type == token.RETURN || tok.Type == token.IDENT || tok.Type == token.BREAK || tok.Type == token.CONTINUE {
 				insertSemi = true
 			}
 		default:
@@ -288,6 +415,7 @@ func (s *Scanner) Next() (tok token.Token) {
 		}
 	}
 	s.insertSemi = insertSemi
+	s.memberName = isMemberAccess(tok.Type)
 	tok.Pos = s.offset
 
 	s.next()
@@ -303,13 +431,9 @@ func (s *Scanner) scanNumber(neg bool) token.Token {
 	}
 	for unicode.IsNumber(s.ch) {
 		literal = append(literal, s.ch)
-		if !s.HasNext() {
-			break
-		} else {
-			s.next()
-		}
+		s.next()
 	}
-	s.backup()
+	s.backup() // roll back last ch which is not a part of number
 	return token.Token{Type: token.NUMBER, Literal: string(literal)}
 }
 
@@ -339,13 +463,22 @@ func (s *Scanner) scanIdentifier() (token.Token) {
 		s.next()
 	}
 	s.backup() // roll back last ch which is not a part of ident
-	if tok, ok := tokens[string(identifier)]; ok {
+	if tok, ok := tokens[string(identifier)]; ok && !s.memberName {
 		return token.Token{Type: tok, Literal: string(identifier)}
 	}
 
 	return token.Token{Type: token.IDENT, Literal: string(identifier)}
 }
 
+// isMemberAccess reports whether t is followed by a name of a variable, a member or a function
+func isMemberAccess(t token.TokenType) bool {
+	switch t {
+	case token.VAR, token.OBJECT_OPERATOR, token.NULLSAFE_OBJECT_OPERATOR, token.PAAMAYIM_NEKUDOTAYIM, token.FUNCTION:
+		return true
+	}
+	return false
+}
+
 func (s *Scanner) scanLineComment() token.Token {
 	com := make([]rune, 0, 256)
 	for {
@@ -6,15 +6,17 @@ import (
 	"reflect"
 )
 
-func run(t *testing.T, input string, expectations []Token) {
+func run(t *testing.T, input string, expectations []token.Token) {
 	s := New([]rune(input))
-	tokens := make([]Token, 0, 2048)
+	tokens := make([]token.Token, 0, 2048)
 
 	for {
 		tok := s.Next()
-		if tok == tokenEof {
+		if tok.Type == token.EOF {
 			break
 		}
+		// positions are not a part of expectations
+		tok.Pos = 0
 		tokens = append(tokens, tok)
 	}
 
@@ -24,7 +26,7 @@ func run(t *testing.T, input string, expectations []Token) {
 }
 
 func TestScanner_Next_If(t *testing.T) {
-	run(t, "if () {} else if () {} else {}", []Token{
+	run(t, "if () {} else if () {} else {}", []token.Token{
 		{Type: token.IF, Literal: "if"},
 		{Type: token.PARENTHESIS_OPENING, Literal: "("},
 		{Type: token.PARENTHESIS_CLOSING, Literal: ")"},
@@ -43,48 +45,115 @@ func TestScanner_Next_If(t *testing.T) {
 }
 
 func TestScanner_Next_Throw(t *testing.T) {
-	run(t, "throw new HttpException", []Token{
+	run(t, "throw new HttpException", []token.Token{
 		{Type: token.THROW, Literal: "throw"},
 		{Type: token.NEW, Literal: "new"},
 		{Type: token.IDENT, Literal: "HttpException"},
 	})
 }
 
+func TestScanner_Next_KeywordMembers(t *testing.T) {
+	run(t, "function match $case->default ?->for A::switch", []token.Token{
+		{Type: token.FUNCTION, Literal: "function"},
+		{Type: token.IDENT, Literal: "match"},
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "case"},
+		{Type: token.OBJECT_OPERATOR, Literal: "->"},
+		{Type: token.IDENT, Literal: "default"},
+		{Type: token.NULLSAFE_OBJECT_OPERATOR, Literal: "?->"},
+		{Type: token.IDENT, Literal: "for"},
+		{Type: token.IDENT, Literal: "A"},
+		{Type: token.PAAMAYIM_NEKUDOTAYIM, Literal: "::"},
+		{Type: token.IDENT, Literal: "switch"},
+	})
+}
+
 func TestScanner_Next_Arithmetic(t *testing.T) {
-	run(t, "$i % 2", []Token{
+	run(t, "$i % 2", []token.Token{
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.MOD, Literal: "%"},
 		{Type: token.NUMBER, Literal: "2"},
 	})
 
-	run(t, "$i += 2", []Token{
+	run(t, "$i += 2", []token.Token{
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.PLUS_EQUAL, Literal: "+="},
 		{Type: token.NUMBER, Literal: "2"},
 	})
 
-	run(t, "$i -= 2", []Token{
+	run(t, "$i -= 2", []token.Token{
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.MINUS_EQUAL, Literal: "-="},
 		{Type: token.NUMBER, Literal: "2"},
 	})
 
-	run(t, "$i /= 2", []Token{
+	run(t, "$i /= 2", []token.Token{
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.DIV_EQUAL, Literal: "/="},
 		{Type: token.NUMBER, Literal: "2"},
 	})
 
-	run(t, "$i *= 2", []Token{
+	run(t, "$i *= 2", []token.Token{
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.MUL_EQUAL, Literal: "*="},
 		{Type: token.NUMBER, Literal: "2"},
 	})
+
+	run(t, "$a <=> $b >= 1", []token.Token{
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.SPACESHIP, Literal: "<=>"},
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.IS_GREATER_OR_EQUAL, Literal: ">="},
+		{Type: token.NUMBER, Literal: "1"},
+	})
+
+	run(t, "1 | 2 ^ 3 << 4 >>= 5", []token.Token{
+		{Type: token.NUMBER, Literal: "1"},
+		{Type: token.BITWISE_OR, Literal: "|"},
+		{Type: token.NUMBER, Literal: "2"},
+		{Type: token.BITWISE_XOR, Literal: "^"},
+		{Type: token.NUMBER, Literal: "3"},
+		{Type: token.SL, Literal: "<<"},
+		{Type: token.NUMBER, Literal: "4"},
+		{Type: token.SR_EQUAL, Literal: ">>="},
+		{Type: token.NUMBER, Literal: "5"},
+	})
+
+	run(t, "$i-1", []token.Token{
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "i"},
+		{Type: token.MINUS, Literal: "-"},
+		{Type: token.NUMBER, Literal: "1"},
+	})
+
+	run(t, "f(-1, ~$i)", []token.Token{
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.PARENTHESIS_OPENING, Literal: "("},
+		{Type: token.NUMBER, Literal: "-1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.BITWISE_NOT, Literal: "~"},
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "i"},
+		{Type: token.PARENTHESIS_CLOSING, Literal: ")"},
+	})
+
+	run(t, "$s . 1 .= ...$a", []token.Token{
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "s"},
+		{Type: token.CONCAT, Literal: "."},
+		{Type: token.NUMBER, Literal: "1"},
+		{Type: token.CONCAT_EQUAL, Literal: ".="},
+		{Type: token.ELLIPSIS, Literal: "..."},
+		{Type: token.VAR, Literal: "$"},
+		{Type: token.IDENT, Literal: "a"},
+	})
 }
 
 func TestScanner_Next_LoopIncDec(t *testing.T) {
@@ -93,16 +162,19 @@ func TestScanner_Next_LoopIncDec(t *testing.T) {
 		$i++
 		--$i
 		$i--`
-	run(t, input, []Token{
+	run(t, input, []token.Token{
 		{Type: token.INC, Literal: "++"},
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
+		{Type: token.SEMICOLON, Literal: ";"},
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.INC, Literal: "++"},
+		{Type: token.SEMICOLON, Literal: ";"},
 		{Type: token.DEC, Literal: "--"},
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
+		{Type: token.SEMICOLON, Literal: ";"},
 		{Type: token.VAR, Literal: "$"},
 		{Type: token.IDENT, Literal: "i"},
 		{Type: token.DEC, Literal: "--"},
@@ -113,7 +185,7 @@ func TestScanner_Next_LoopFor(t *testing.T) {
 	var input = `
 for($i = 0; $i <= 5; ++$i) {}
 `
-	run(t, input, []Token{
+	run(t, input, []token.Token{
 		{Type: token.FOR, Literal: "for"},
 		{Type: token.PARENTHESIS_OPENING, Literal: "("},
 		{Type: token.VAR, Literal: "$"},
@@ -132,6 +204,7 @@ for($i = 0; $i <= 5; ++$i) {}
 		{Type: token.PARENTHESIS_CLOSING, Literal: ")"},
 		{Type: token.CURLY_OPENING, Literal: "{"},
 		{Type: token.CURLY_CLOSING, Literal: "}"},
+		{Type: token.SEMICOLON, Literal: ";"},
 	})
 }
 
@@ -139,7 +212,7 @@ func TestScanner_Next_LoopForeach(t *testing.T) {
 	var input = `
 foreach ($array as $key => $value) {}
 `
-	run(t, input, []Token{
+	run(t, input, []token.Token{
 		{Type: token.FOREACH, Literal: "foreach"},
 		{Type: token.PARENTHESIS_OPENING, Literal: "("},
 		{Type: token.VAR, Literal: "$"},
@@ -153,6 +226,7 @@ foreach ($array as $key => $value) {}
 		{Type: token.PARENTHESIS_CLOSING, Literal: ")"},
 		{Type: token.CURLY_OPENING, Literal: "{"},
 		{Type: token.CURLY_CLOSING, Literal: "}"},
+		{Type: token.SEMICOLON, Literal: ";"},
 	})
 }
 
@@ -168,7 +242,7 @@ class Application extends Container implements HttpKernelInterface {
         parent::register($values);
     }
 }`
-	expectations := []Token{
+	expectations := []token.Token{
 		// namespace Silex;
 		{Type: token.NAMESPACE, Literal: "namespace"},
 		{Type: token.IDENT, Literal: "Silex"},
@@ -218,6 +292,7 @@ class Application extends Container implements HttpKernelInterface {
 		{Type: token.SEMICOLON, Literal: ";"},
 		// }
 		{Type: token.CURLY_CLOSING, Literal: "}"},
+		{Type: token.SEMICOLON, Literal: ";"},
 		// }
 		{Type: token.CURLY_CLOSING, Literal: "}"},
 	}
@@ -238,7 +313,7 @@ func TestScanner_Next_Function(t *testing.T) {
         return $response;
     }`
 
-	expectations := []Token{
+	expectations := []token.Token{
 		// function render($view, array $parameters = []): Response
 		{Type: token.FUNCTION, Literal: "function"},
 		{Type: token.IDENT, Literal: "render"},
@@ -255,6 +330,7 @@ func TestScanner_Next_Function(t *testing.T) {
 		{Type: token.PARENTHESIS_CLOSING, Literal: ")"},
 		{Type: token.COLON, Literal: ":"},
 		{Type: token.IDENT, Literal: "Response"},
+		{Type: token.SEMICOLON, Literal: ";"},
 		// {
 		{Type: token.CURLY_OPENING, Literal: "{"},
 		// $twig = $this['twig'];
@@ -318,6 +394,7 @@ func TestScanner_Next_Function(t *testing.T) {
 		{Type: token.SEMICOLON, Literal: ";"},
 		// }
 		{Type: token.CURLY_CLOSING, Literal: "}"},
+		{Type: token.SEMICOLON, Literal: ";"},
 		// return $response;
 		{Type: token.RETURN, Literal: "return"},
 		{Type: token.VAR, Literal: "$"},
@@ -352,7 +429,7 @@ use Symfony\Component\HttpKernel\Event\GetResponseForExceptionEvent;
 use Symfony\Component\HttpKernel\KernelEvents;
 
 `
-	expectations := []Token{
+	expectations := []token.Token{
 		{Type: token.COMMENT, Literal: `/*
  * This file is part of the Silex framework.
  *
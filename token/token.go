@@ -96,6 +96,7 @@ const (
 	ENDSWITCH                 /* "endswitch"			*/
 	CASE                      /* "case"			*/
 	DEFAULT                   /* "default"			*/
+	MATCH                     /* "match"			*/
 	BREAK                     /* "break"			*/
 	CONTINUE                  /* "continue"			*/
 	GOTO                      /* "goto"			*/
@@ -156,7 +157,15 @@ const (
 	NS_SEPARATOR              /* "\\"			*/
 	ELLIPSIS                  /* "..."			*/
 	COALESCE                  /* "??"			*/
+	COALESCE_EQUAL            /* "??="			*/
 	POW                       /* "**"			*/
 	POW_EQUAL                 /* "**="			*/
+	AMPERSAND                 /* "&"			*/
+	BITWISE_NOT               /* "~"			*/
+	NULLSAFE_OBJECT_OPERATOR  /* "?->"			*/
+	BITWISE_OR                /* "|"			*/
+	BITWISE_XOR               /* "^"			*/
+	QUESTION_MARK             /* "?"			*/
+	CONCAT                    /* "."			*/
 	NEWLINE
 )